@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"git.neds.sh/matty/entain/racing/auth"
+	"google.golang.org/grpc/codes"
+)
+
+// withAuth rejects requests whose bearer token can't be verified with 401 Unauthorized, before they're
+// forwarded. Requests without a token are passed on anonymously, for the services to decide what
+// anonymous callers may do.
+//
+// Tokens are forwarded to the services in the "authorization" metadata, as usual, for them to verify
+// again and authorise the caller by.
+func withAuth(next http.Handler, verifier *auth.Verifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if authorization == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := verifier.VerifyAuthorization(authorization); err != nil {
+			body, _ := json.Marshal(map[string]interface{}{"code": codes.Unauthenticated, "message": err.Error(), "details": []string{}})
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(body)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/auth/authtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAuth(t *testing.T) {
+	issuer := authtest.NewIssuer(t)
+
+	var forwarded bool
+	handler := withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = true
+	}), issuer.Verifier(t))
+
+	serve := func(authorization string) *httptest.ResponseRecorder {
+		forwarded = false
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/races", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("").Code)
+	assert.True(t, forwarded, "Anonymous callers are left for the services to authorise.")
+
+	assert.Equal(t, http.StatusOK, serve("Bearer "+issuer.Token(t, "alice", "trader", "admin")).Code)
+	assert.True(t, forwarded)
+
+	expired := authtest.Sign(t, issuer.Key, authtest.KeyID, "alice", -time.Minute, "admin")
+	for _, authorization := range []string{"Bearer " + expired, "Bearer forged", "Basic YWxpY2U6c2VjcmV0"} {
+		w := serve(authorization)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "invalid_token")
+		assert.JSONEq(t, `16`, string(mustField(t, w.Body.Bytes(), "code")))
+		assert.False(t, forwarded)
+	}
+}
+
+func mustField(t *testing.T, body []byte, field string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &fields))
+
+	return fields[field]
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxAges are the Cache-Control max-ages of the GET routes, by path pattern. Prices and results
+// change as races run, so they're cached the least.
+const defaultMaxAges = "/v1/races=5s,/v1/races:search=30s,/v1/races/*=5s,/v1/races/*/runners=5s," +
+	"/v1/races/*/prices=1s,/v1/races/*/results=5s,/v1/meetings/*=60s"
+
+// maxAgeRule sets the max-age of responses to paths matching a pattern.
+type maxAgeRule struct {
+	// segments of the pattern, where "*" matches any single segment.
+	segments []string
+	maxAge   time.Duration
+}
+
+// cachePolicy decides how long GET responses may be cached for.
+type cachePolicy struct {
+	rules []maxAgeRule
+	// nextToJump is the max-age of next to jump race lists, which change as soon as a race jumps.
+	nextToJump time.Duration
+}
+
+// parseCachePolicy parses comma separated "pattern=duration" max-age rules, e.g. "/v1/races/*=5s".
+// Paths matching none of the rules may be stored, but must be revalidated on every use.
+func parseCachePolicy(rules string, nextToJump time.Duration) (*cachePolicy, error) {
+	policy := &cachePolicy{nextToJump: nextToJump}
+
+	for _, rule := range strings.Split(rules, ",") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid max-age rule %q, want pattern=duration", rule)
+		}
+
+		maxAge, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || maxAge < 0 {
+			return nil, fmt.Errorf("invalid max-age rule %q: invalid duration", rule)
+		}
+
+		policy.rules = append(policy.rules, maxAgeRule{
+			segments: strings.Split(strings.Trim(strings.TrimSpace(parts[0]), "/"), "/"),
+			maxAge:   maxAge,
+		})
+	}
+
+	return policy, nil
+}
+
+// cacheControl returns the Cache-Control header of a successful response to r. Responses to
+// authenticated requests depend on the caller, e.g. on whether they can see invisible races, so they
+// may only be cached privately.
+func (p *cachePolicy) cacheControl(r *http.Request) string {
+	scope := "public"
+	if r.Header.Get("Authorization") != "" {
+		scope = "private"
+	}
+
+	if r.URL.Path == "/v1/races" {
+		if n, err := strconv.Atoi(r.URL.Query().Get("filter.next_to_jump")); err == nil && n > 0 {
+			return maxAgeHeader(scope, p.nextToJump)
+		}
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	for _, rule := range p.rules {
+		if rule.matches(segments) {
+			return maxAgeHeader(scope, rule.maxAge)
+		}
+	}
+
+	return "private, no-cache"
+}
+
+func (r maxAgeRule) matches(segments []string) bool {
+	if len(segments) != len(r.segments) {
+		return false
+	}
+
+	for i, segment := range r.segments {
+		if segment != "*" && segment != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func maxAgeHeader(scope string, maxAge time.Duration) string {
+	return scope + ", max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// withCaching adds caching headers to the successful GET responses of next: an ETag computed over the
+// response body, and a Cache-Control max-age decided by the policy. Requests whose If-None-Match names
+// the ETag are answered with 304 Not Modified and no body.
+//
+// Responses are buffered to compute their ETag, unless the handler flushes them, as streams do, in
+// which case they're passed through without caching headers.
+func withCaching(next http.Handler, policy *cachePolicy) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.streaming {
+			return
+		}
+
+		if rec.status == http.StatusOK {
+			sum := sha256.Sum256(rec.body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", policy.cacheControl(r))
+			w.Header().Set("Vary", "Authorization")
+
+			if etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+		}
+
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	})
+}
+
+// etagMatches reports whether an If-None-Match header names the ETag, using the weak comparison
+// If-None-Match calls for.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bufferedResponse holds back a response's status and body, until it's flushed.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.streaming {
+		b.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.streaming {
+		return b.ResponseWriter.Write(p)
+	}
+
+	return b.body.Write(p)
+}
+
+// Flush switches to streaming the response, sending what was buffered so far.
+func (b *bufferedResponse) Flush() {
+	if !b.streaming {
+		b.streaming = true
+		b.ResponseWriter.WriteHeader(b.status)
+		b.ResponseWriter.Write(b.body.Bytes())
+	}
+
+	if flusher, ok := b.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
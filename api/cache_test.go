@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCaching(t *testing.T) {
+	policy, err := parseCachePolicy(defaultMaxAges, time.Second)
+	require.NoError(t, err)
+
+	body := `{"races":[]}`
+	handler := withCaching(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/races/1000" {
+			http.Error(w, `{"code":5}`, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}), policy)
+
+	serve := func(method, target, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	first := serve(http.MethodGet, "/v1/races?filter.meeting_ids=1", "")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, body, first.Body.String())
+	assert.Equal(t, "public, max-age=5", first.Header().Get("Cache-Control"))
+
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// The same response is given the same ETag, which revalidates it.
+	notModified := serve(http.MethodGet, "/v1/races?filter.meeting_ids=1", `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+	assert.Empty(t, notModified.Header().Get("Content-Type"))
+
+	// Once the response changes, so does its ETag.
+	body = `{"races":[{"id":"1"}]}`
+	changed := serve(http.MethodGet, "/v1/races?filter.meeting_ids=1", etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+
+	assert.Equal(t, "public, max-age=1", serve(http.MethodGet, "/v1/races?filter.next_to_jump=5", "").Header().Get("Cache-Control"))
+	assert.Equal(t, "public, max-age=60", serve(http.MethodGet, "/v1/meetings/3", "").Header().Get("Cache-Control"))
+	assert.Equal(t, "private, no-cache", serve(http.MethodGet, "/v1/bets/3", "").Header().Get("Cache-Control"))
+
+	// Responses to authenticated requests depend on the caller.
+	authenticated := httptest.NewRequest(http.MethodGet, "/v1/races", nil)
+	authenticated.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, authenticated)
+	assert.Equal(t, "private, max-age=5", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "Authorization", w.Header().Get("Vary"))
+
+	// Errors and writes aren't cached.
+	notFound := serve(http.MethodGet, "/v1/races/1000", "")
+	assert.Equal(t, http.StatusNotFound, notFound.Code)
+	assert.Empty(t, notFound.Header().Get("ETag"))
+	assert.Empty(t, notFound.Header().Get("Cache-Control"))
+
+	posted := serve(http.MethodPost, "/v1/list-races", changed.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, posted.Code)
+	assert.Empty(t, posted.Header().Get("ETag"))
+}
+
+func TestWithCaching_Streams(t *testing.T) {
+	policy, err := parseCachePolicy("", time.Second)
+	require.NoError(t, err)
+
+	handler := withCaching(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("data: 2\n\n"))
+	}), policy)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/races:watch", nil))
+
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", w.Body.String())
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestParseCachePolicy(t *testing.T) {
+	for _, rules := range []string{"/v1/races", "/v1/races=soon", "/v1/races=-1s"} {
+		_, err := parseCachePolicy(rules, time.Second)
+		assert.Errorf(t, err, "Rules %q", rules)
+	}
+}
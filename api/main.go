@@ -5,15 +5,32 @@ import (
 	"flag"
 	"log"
 	"net/http"
+	"time"
 
+	"git.neds.sh/matty/entain/api/proto/betting"
 	"git.neds.sh/matty/entain/api/proto/racing"
+	"git.neds.sh/matty/entain/api/proto/sports"
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/certs"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 )
 
 var (
-	apiEndpoint  = flag.String("api-endpoint", "localhost:8000", "API endpoint")
-	grpcEndpoint = flag.String("grpc-endpoint", "localhost:9000", "gRPC server endpoint")
+	apiEndpoint     = flag.String("api-endpoint", "localhost:8000", "API endpoint")
+	grpcEndpoint    = flag.String("grpc-endpoint", "localhost:9000", "gRPC server endpoint")
+	sportsEndpoint  = flag.String("sports-grpc-endpoint", "localhost:9001", "Sports gRPC server endpoint")
+	bettingEndpoint = flag.String("betting-grpc-endpoint", "localhost:9002", "Betting gRPC server endpoint")
+	jwksPath        = flag.String("jwks", "", "JSON Web Key Set file of the keys trusted to sign bearer tokens, verified before requests are forwarded")
+	maxAges         = flag.String("max-age", defaultMaxAges, "comma separated Cache-Control max-ages of GET routes, as path=duration, where * in a path matches any segment")
+	nextToJumpAge   = flag.Duration("next-to-jump-max-age", time.Second, "Cache-Control max-age of next to jump race lists")
+	tlsCert         = flag.String("tls-cert", "", "PEM certificate file to serve HTTPS with, along with -tls-key")
+	tlsKey          = flag.String("tls-key", "", "PEM private key file of -tls-cert")
+	racingTLSCA     = flag.String("racing-tls-ca", "", "PEM file of the CAs trusted to sign the racing service's certificate, connecting to it over TLS")
+	racingTLSCert   = flag.String("racing-tls-cert", "", "PEM client certificate file to present to the racing service, along with -racing-tls-key, connecting to it over TLS")
+	racingTLSKey    = flag.String("racing-tls-key", "", "PEM private key file of -racing-tls-cert")
+	racingTLSName   = flag.String("racing-tls-server-name", "", "name the racing service's certificate must be valid for, by default the host of -grpc-endpoint")
+	tlsReload       = flag.Duration("tls-reload-interval", 10*time.Second, "how often the TLS certificate files are checked for changes")
 )
 
 func main() {
@@ -25,21 +42,78 @@ func main() {
 }
 
 func run() error {
+	policy, err := parseCachePolicy(*maxAges, *nextToJumpAge)
+	if err != nil {
+		return err
+	}
+
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	mux := runtime.NewServeMux()
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(mimeEventStream, &eventStreamMarshaler{}),
+	)
+	racingCreds, err := certs.DialOption(*racingTLSCA, *racingTLSCert, *racingTLSKey, *racingTLSName, *grpcEndpoint, *tlsReload)
+	if err != nil {
+		return err
+	}
+
 	if err := racing.RegisterRacingHandlerFromEndpoint(
 		ctx,
 		mux,
 		*grpcEndpoint,
+		[]grpc.DialOption{racingCreds},
+	); err != nil {
+		return err
+	}
+
+	if err := sports.RegisterSportsHandlerFromEndpoint(
+		ctx,
+		mux,
+		*sportsEndpoint,
 		[]grpc.DialOption{grpc.WithInsecure()},
 	); err != nil {
 		return err
 	}
 
+	if err := betting.RegisterBettingHandlerFromEndpoint(
+		ctx,
+		mux,
+		*bettingEndpoint,
+		[]grpc.DialOption{grpc.WithInsecure()},
+	); err != nil {
+		return err
+	}
+
+	handler := withCaching(mux, policy)
+
+	// Without keys, tokens are left for the services to verify.
+	if *jwksPath != "" {
+		verifier, err := auth.LoadVerifier(*jwksPath)
+		if err != nil {
+			return err
+		}
+
+		handler = withAuth(handler, verifier)
+	}
+
+	server := &http.Server{Addr: *apiEndpoint, Handler: handler}
+
+	if *tlsCert != "" {
+		reloader, err := certs.NewReloader(*tlsCert, *tlsKey, "", *tlsReload)
+		if err != nil {
+			return err
+		}
+
+		server.TLSConfig = reloader.ServerConfig()
+
+		log.Printf("API server listening with TLS on: %s\n", *apiEndpoint)
+
+		return server.ListenAndServeTLS("", "")
+	}
+
 	log.Printf("API server listening on: %s\n", *apiEndpoint)
 
-	return http.ListenAndServe(*apiEndpoint, mux)
+	return server.ListenAndServe()
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// mimeEventStream is the content type browsers' EventSource requests and expects back.
+const mimeEventStream = "text/event-stream"
+
+// eventStreamMarshaler renders streamed responses as server-sent events, so browsers can subscribe
+// to streaming RPCs such as WatchRaces with an EventSource. Each message is sent as a single "data"
+// field holding its usual JSON representation.
+type eventStreamMarshaler struct {
+	runtime.JSONPb
+}
+
+// ContentType always reports the event stream content type.
+func (m *eventStreamMarshaler) ContentType(_ interface{}) string {
+	return mimeEventStream
+}
+
+// Marshal renders v as the data field of an event.
+func (m *eventStreamMarshaler) Marshal(v interface{}) ([]byte, error) {
+	b, err := m.JSONPb.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte("data: "), b...), nil
+}
+
+// Delimiter terminates each event with the blank line the event stream format requires.
+func (m *eventStreamMarshaler) Delimiter() []byte {
+	return []byte("\n\n")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/api/proto/racing"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestEventStreamMarshaler_WatchRaces(t *testing.T) {
+	policy, err := parseCachePolicy(defaultMaxAges, time.Second)
+	require.NoError(t, err)
+
+	watcher := &fakeWatcher{release: make(chan struct{})}
+	server := httptest.NewServer(withCaching(watchMux(t, watcher), policy))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/races:watch?meeting_ids=1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", mimeEventStream)
+
+	// Reads block until the events are flushed, failing the test should they be held back.
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, mimeEventStream, resp.Header.Get("Content-Type"))
+	assert.Empty(t, resp.Header.Get("ETag"))
+
+	body := bufio.NewReader(resp.Body)
+
+	// The first event arrives while the stream is still open...
+	event := readEvent(t, body)
+	assert.True(t, strings.HasPrefix(event, "data: {"), event)
+	assert.Contains(t, event, `"type":"SNAPSHOT"`)
+	assert.Contains(t, event, `"name":"Zephyrine Stakes"`)
+	assert.NotContains(t, strings.TrimSuffix(event, "\n\n"), "\n")
+
+	// ... and the next once it's sent, before the stream ends.
+	close(watcher.release)
+
+	event = readEvent(t, body)
+	assert.True(t, strings.HasPrefix(event, "data: {"), event)
+	assert.Contains(t, event, `"type":"UPDATED"`)
+
+	rest, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+}
+
+// fakeWatcher streams a race's snapshot, and, once released, its update.
+type fakeWatcher struct {
+	racing.UnimplementedRacingServer
+	release chan struct{}
+}
+
+func (w *fakeWatcher) WatchRaces(in *racing.ListRacesRequestFilter, stream racing.Racing_WatchRacesServer) error {
+	race := &racing.Race{Id: 1, MeetingId: 1, Name: "Zephyrine Stakes", Visible: true}
+
+	if err := stream.Send(&racing.RaceEvent{Type: racing.RaceEvent_SNAPSHOT, Race: race}); err != nil {
+		return err
+	}
+
+	select {
+	case <-w.release:
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	}
+
+	return stream.Send(&racing.RaceEvent{Type: racing.RaceEvent_UPDATED, Race: race})
+}
+
+// watchMux serves the racing service over an in-memory connection, returning the gateway's mux in front
+// of it, rendering event streams as the api service does.
+func watchMux(t *testing.T, srv racing.RacingServer) *runtime.ServeMux {
+	ln := bufconn.Listen(1 << 20)
+
+	server := grpc.NewServer()
+	racing.RegisterRacingServer(server, srv)
+
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(
+		"bufconn",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.Dial() }),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(mimeEventStream, &eventStreamMarshaler{}),
+	)
+	require.NoError(t, racing.RegisterRacingHandlerClient(context.Background(), mux, racing.NewRacingClient(conn)))
+
+	return mux
+}
+
+// readEvent reads a single event, up to and including the blank line ending it.
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	var event strings.Builder
+	for !strings.HasSuffix(event.String(), "\n\n") {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err, "reading event %q", event.String())
+
+		event.WriteString(line)
+	}
+
+	return event.String()
+}
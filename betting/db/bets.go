@@ -0,0 +1,240 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"git.neds.sh/matty/entain/betting/proto/betting"
+)
+
+// ErrBetNotFound is returned when a bet cannot be found for a given ID.
+var ErrBetNotFound = errors.New("bet not found")
+
+// BetsRepo provides repository access to bets.
+type BetsRepo interface {
+	// Create will store a new bet, returning it with its ID.
+	Create(bet *betting.Bet) (*betting.Bet, error)
+
+	// Get will return a single bet by its ID, or ErrBetNotFound if it doesn't exist.
+	Get(id int64) (*betting.Bet, error)
+
+	// List will return a page of the bets matching the request's filter, most recently placed first,
+	// along with the token of the next page, if there is one. A zero page size returns every matching bet.
+	List(in *betting.ListBetsRequest) ([]*betting.Bet, string, error)
+
+	// Settle will record the status, payout and settled time of bets in a single transaction. Bets
+	// are only ever settled once, so settling an already settled bet is a no-op. It returns the number
+	// of bets that were newly settled.
+	Settle(bets []*betting.Bet) (int, error)
+}
+
+type betsRepo struct {
+	db *sql.DB
+}
+
+// NewBetsRepo creates a new bets repository.
+func NewBetsRepo(db *sql.DB) BetsRepo {
+	return &betsRepo{db: db}
+}
+
+func (r *betsRepo) Create(bet *betting.Bet) (*betting.Bet, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO bets(customer_id, race_id, runner_id, type, stake, win_price, place_price, placed_time, status) VALUES (?,?,?,?,?,?,?,?,?)`,
+		bet.CustomerId,
+		bet.RaceId,
+		bet.RunnerId,
+		int32(bet.Type),
+		bet.Stake,
+		bet.WinPrice,
+		bet.PlacePrice,
+		bet.PlacedTime.AsTime().UTC().Format(time.RFC3339),
+		int32(bet.Status),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Get(id)
+}
+
+func (r *betsRepo) Get(id int64) (*betting.Bet, error) {
+	rows, err := r.db.Query(getBetQueries()[betsList]+" WHERE bets.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	bets, err := r.scanBets(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bets) == 0 {
+		return nil, ErrBetNotFound
+	}
+
+	return bets[0], nil
+}
+
+func (r *betsRepo) List(in *betting.ListBetsRequest) ([]*betting.Bet, string, error) {
+	var (
+		filter  = in.GetFilter()
+		clauses []string
+		args    []interface{}
+	)
+
+	size, err := pageSize(in.GetPageSize())
+	if err != nil {
+		return nil, "", err
+	}
+
+	fingerprint, err := filterFingerprint(filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if filter.GetCustomerId() != "" {
+		clauses = append(clauses, "customer_id = ?")
+		args = append(args, filter.CustomerId)
+	}
+
+	if len(filter.GetRaceIds()) > 0 {
+		clauses = append(clauses, "bets.race_id IN ("+strings.Repeat("?,", len(filter.RaceIds)-1)+"?)")
+
+		for _, raceID := range filter.RaceIds {
+			args = append(args, raceID)
+		}
+	}
+
+	if len(filter.GetStatuses()) > 0 {
+		clauses = append(clauses, betStatusExpr+" IN ("+strings.Repeat("?,", len(filter.Statuses)-1)+"?)")
+
+		for _, status := range filter.Statuses {
+			args = append(args, int32(status))
+		}
+	}
+
+	if in.GetPageToken() != "" {
+		cursor, err := decodePageToken(in.PageToken, fingerprint)
+		if err != nil {
+			return nil, "", err
+		}
+
+		clauses = append(clauses, "(bets.placed_time < ? OR (bets.placed_time = ? AND bets.id < ?))")
+		args = append(args, cursor.PlacedTime, cursor.PlacedTime, cursor.ID)
+	}
+
+	query := getBetQueries()[betsList]
+	if len(clauses) != 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	query += " ORDER BY bets.placed_time DESC, bets.id DESC"
+
+	// One more bet than a page is fetched, to tell whether there's a next page.
+	if size > 0 {
+		query += " LIMIT ?"
+		args = append(args, size+1)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bets, err := r.scanBets(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if size == 0 || len(bets) <= size {
+		return bets, "", nil
+	}
+
+	bets = bets[:size]
+
+	nextPageToken, err := encodePageToken(fingerprint, bets[size-1])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return bets, nextPageToken, nil
+}
+
+func (r *betsRepo) Settle(bets []*betting.Bet) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var settled int
+	for _, bet := range bets {
+		res, err := tx.Exec(
+			`INSERT INTO settlements(bet_id, status, payout, settled_time) VALUES (?,?,?,?) ON CONFLICT (bet_id) DO NOTHING`,
+			bet.Id,
+			int32(bet.Status),
+			bet.Payout,
+			bet.SettledTime.AsTime().UTC().Format(time.RFC3339),
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		settled += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return settled, nil
+}
+
+func (r *betsRepo) scanBets(
+	rows *sql.Rows,
+) ([]*betting.Bet, error) {
+	defer rows.Close()
+
+	var bets []*betting.Bet
+
+	for rows.Next() {
+		var (
+			bet         betting.Bet
+			betType     int32
+			status      int32
+			placedTime  time.Time
+			settledTime sql.NullTime
+		)
+
+		if err := rows.Scan(&bet.Id, &bet.CustomerId, &bet.RaceId, &bet.RunnerId, &betType, &bet.Stake, &bet.WinPrice, &bet.PlacePrice, &placedTime, &status, &bet.Payout, &settledTime); err != nil {
+			return nil, err
+		}
+
+		bet.Type = betting.Bet_Type(betType)
+		bet.Status = betting.Bet_Status(status)
+		bet.PlacedTime = timestamppb.New(placedTime)
+
+		if settledTime.Valid {
+			bet.SettledTime = timestamppb.New(settledTime.Time)
+		}
+
+		bets = append(bets, &bet)
+	}
+
+	return bets, rows.Err()
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestBetsRepo_Create(t *testing.T) {
+	betsRepo := createRepo(t)
+
+	placed := time.Date(2031, 1, 1, 1, 0, 0, 0, time.UTC)
+	bet, err := betsRepo.Create(&betting.Bet{
+		CustomerId: "alice",
+		RaceId:     1,
+		RunnerId:   2,
+		Type:       betting.Bet_EACH_WAY,
+		Stake:      500,
+		WinPrice:   5.5,
+		PlacePrice: 2.1,
+		PlacedTime: timestamppb.New(placed),
+		Status:     betting.Bet_PENDING,
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, bet.Id)
+	assert.Equal(t, betting.Bet_EACH_WAY, bet.Type)
+	assert.Equal(t, placed, bet.PlacedTime.AsTime())
+
+	got, err := betsRepo.Get(bet.Id)
+	require.NoError(t, err)
+	assert.Equal(t, bet.Stake, got.Stake)
+	assert.Equal(t, bet.PlacePrice, got.PlacePrice)
+
+	_, err = betsRepo.Get(1000)
+	assert.ErrorIs(t, err, ErrBetNotFound)
+}
+
+func TestBetsRepo_List(t *testing.T) {
+	betsRepo := createRepo(t)
+
+	start := time.Date(2031, 1, 1, 1, 0, 0, 0, time.UTC)
+	for i, bet := range []struct {
+		customerID string
+		raceID     int64
+	}{{"alice", 1}, {"bob", 1}, {"alice", 2}} {
+		_, err := betsRepo.Create(&betting.Bet{
+			CustomerId: bet.customerID,
+			RaceId:     bet.raceID,
+			RunnerId:   1,
+			Type:       betting.Bet_WIN,
+			Stake:      100,
+			WinPrice:   2,
+			PlacedTime: timestamppb.New(start.Add(time.Duration(i) * time.Minute)),
+			Status:     betting.Bet_PENDING,
+		})
+		require.NoError(t, err)
+	}
+
+	bets, _, err := betsRepo.List(&betting.ListBetsRequest{})
+	require.NoError(t, err)
+	if assert.Len(t, bets, 3) {
+		// Most recently placed first.
+		assert.Equal(t, int64(2), bets[0].RaceId)
+	}
+
+	bets, _, err = betsRepo.List(&betting.ListBetsRequest{Filter: &betting.ListBetsRequestFilter{CustomerId: "alice"}})
+	require.NoError(t, err)
+	assert.Len(t, bets, 2)
+
+	bets, _, err = betsRepo.List(&betting.ListBetsRequest{Filter: &betting.ListBetsRequestFilter{CustomerId: "alice", RaceIds: []int64{1}}})
+	require.NoError(t, err)
+	assert.Len(t, bets, 1)
+}
+
+func TestBetsRepo_ListPages(t *testing.T) {
+	betsRepo := createRepo(t)
+
+	// Bets placed at the same time are paged through by their IDs.
+	start := time.Date(2031, 1, 1, 1, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		bet, err := betsRepo.Create(&betting.Bet{
+			CustomerId: "alice",
+			RaceId:     1,
+			RunnerId:   1,
+			Type:       betting.Bet_WIN,
+			Stake:      100,
+			WinPrice:   2,
+			PlacedTime: timestamppb.New(start.Add(time.Duration(i/2) * time.Minute)),
+			Status:     betting.Bet_PENDING,
+		})
+		require.NoError(t, err)
+
+		ids = append([]int64{bet.Id}, ids...)
+	}
+
+	filter := &betting.ListBetsRequestFilter{CustomerId: "alice"}
+
+	var (
+		got   []int64
+		token string
+		pages int
+	)
+	for {
+		bets, next, err := betsRepo.List(&betting.ListBetsRequest{Filter: filter, PageSize: 2, PageToken: token})
+		require.NoError(t, err)
+
+		for _, bet := range bets {
+			got = append(got, bet.Id)
+		}
+
+		pages++
+		if token = next; token == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, ids, got)
+	assert.Equal(t, 3, pages)
+
+	// Tokens only continue the listing they came from.
+	_, next, err := betsRepo.List(&betting.ListBetsRequest{Filter: filter, PageSize: 2})
+	require.NoError(t, err)
+
+	_, _, err = betsRepo.List(&betting.ListBetsRequest{Filter: &betting.ListBetsRequestFilter{CustomerId: "bob"}, PageSize: 2, PageToken: next})
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+
+	_, _, err = betsRepo.List(&betting.ListBetsRequest{Filter: filter, PageToken: "not a token"})
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+
+	_, _, err = betsRepo.List(&betting.ListBetsRequest{Filter: filter, PageSize: -1})
+	assert.ErrorIs(t, err, ErrInvalidPageSize)
+}
+
+func TestBetsRepo_Settle(t *testing.T) {
+	betsRepo := createRepo(t)
+
+	bet, err := betsRepo.Create(&betting.Bet{
+		CustomerId: "alice",
+		RaceId:     1,
+		RunnerId:   1,
+		Type:       betting.Bet_WIN,
+		Stake:      100,
+		WinPrice:   3,
+		PlacedTime: timestamppb.Now(),
+		Status:     betting.Bet_PENDING,
+	})
+	require.NoError(t, err)
+
+	settledTime := time.Date(2031, 1, 1, 2, 0, 0, 0, time.UTC)
+	settled, err := betsRepo.Settle([]*betting.Bet{{Id: bet.Id, Status: betting.Bet_WON, Payout: 300, SettledTime: timestamppb.New(settledTime)}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, settled)
+
+	// Bets are only settled once.
+	settled, err = betsRepo.Settle([]*betting.Bet{{Id: bet.Id, Status: betting.Bet_LOST, SettledTime: timestamppb.Now()}})
+	require.NoError(t, err)
+	assert.Zero(t, settled)
+
+	got, err := betsRepo.Get(bet.Id)
+	require.NoError(t, err)
+	assert.Equal(t, betting.Bet_WON, got.Status)
+	assert.Equal(t, int64(300), got.Payout)
+	assert.Equal(t, settledTime, got.SettledTime.AsTime())
+
+	pending, _, err := betsRepo.List(&betting.ListBetsRequest{Filter: &betting.ListBetsRequestFilter{Statuses: []betting.Bet_Status{betting.Bet_PENDING}}})
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func createRepo(t *testing.T) BetsRepo {
+	bettingDB := openDB(t)
+
+	migrator, err := NewMigrator(bettingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	repo := NewBetsRepo(bettingDB)
+
+	return repo
+}
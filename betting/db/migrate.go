@@ -0,0 +1,25 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+
+	"git.neds.sh/matty/entain/racing/migrate"
+)
+
+var (
+	// ErrSchemaBehind is returned when a database has migrations that haven't been applied.
+	ErrSchemaBehind = migrate.ErrSchemaBehind
+	// ErrSchemaAhead is returned when a database has migrations applied that this build doesn't know.
+	ErrSchemaAhead = migrate.ErrSchemaAhead
+)
+
+// migrationFiles holds the migrations of the betting database.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// NewMigrator creates a new migrator applying the betting migrations to the given database.
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrationFiles, "migrations", migrate.SQLite)
+}
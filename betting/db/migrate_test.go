@@ -0,0 +1,83 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator_UpDown(t *testing.T) {
+	bettingDB := openDB(t)
+
+	migrator, err := NewMigrator(bettingDB)
+	require.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(), ErrSchemaBehind)
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Len(t, applied, int(migrator.Latest()))
+	assert.NoError(t, migrator.Check())
+
+	// Applying again is a no-op.
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.Len(t, statuses, int(migrator.Latest()))
+
+	// Every down script has to undo its up script, so the schema can be rebuilt from scratch.
+	reverted, err := migrator.Down(len(statuses))
+	assert.NoError(t, err)
+	assert.Len(t, reverted, len(statuses))
+
+	var tables int
+	assert.NoError(t, bettingDB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations'`).Scan(&tables))
+	assert.Zero(t, tables)
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.NoError(t, migrator.Check())
+}
+
+func TestMigrator_AdoptsLegacyDatabase(t *testing.T) {
+	bettingDB := openDB(t)
+
+	// Databases created before migrations were introduced have the bets and settlements tables already.
+	for _, statement := range []string{
+		`CREATE TABLE bets (id INTEGER PRIMARY KEY, customer_id TEXT NOT NULL, race_id INTEGER NOT NULL, runner_id INTEGER NOT NULL, type INTEGER NOT NULL, stake INTEGER NOT NULL, win_price REAL NOT NULL, place_price REAL NOT NULL, placed_time DATETIME NOT NULL, status INTEGER NOT NULL)`,
+		`CREATE INDEX bets_race_id ON bets (race_id)`,
+		`CREATE TABLE settlements (bet_id INTEGER PRIMARY KEY, status INTEGER NOT NULL, payout INTEGER NOT NULL, settled_time DATETIME NOT NULL)`,
+		`INSERT INTO bets VALUES (1, 'alice', 1, 1, 1, 100, 3, 0, '2031-01-01T01:00:00Z', 1)`,
+		`INSERT INTO settlements VALUES (1, 2, 300, '2031-01-01T02:00:00Z')`,
+	} {
+		_, err := bettingDB.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	migrator, err := NewMigrator(bettingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	bet, err := NewBetsRepo(bettingDB).Get(1)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", bet.CustomerId)
+	assert.Equal(t, betting.Bet_WON, bet.Status)
+	assert.Equal(t, int64(300), bet.Payout)
+}
+
+// openDB opens an empty in-memory database.
+func openDB(t *testing.T) *sql.DB {
+	bettingDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	// Keep the in-memory database alive across the pool's connections.
+	bettingDB.SetMaxOpenConns(1)
+
+	return bettingDB
+}
@@ -0,0 +1,3 @@
+-- Databases created before migrations were introduced already have this table, which is adopted as is.
+CREATE TABLE IF NOT EXISTS bets (id INTEGER PRIMARY KEY, customer_id TEXT NOT NULL, race_id INTEGER NOT NULL, runner_id INTEGER NOT NULL, type INTEGER NOT NULL, stake INTEGER NOT NULL, win_price REAL NOT NULL, place_price REAL NOT NULL, placed_time DATETIME NOT NULL, status INTEGER NOT NULL);
+CREATE INDEX IF NOT EXISTS bets_race_id ON bets (race_id);
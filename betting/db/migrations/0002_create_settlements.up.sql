@@ -0,0 +1,3 @@
+-- Settlements are kept apart from the bets they settle, and keyed by them, so a bet can only ever be settled once.
+-- Databases created before migrations were introduced may already have this table, which is adopted as is.
+CREATE TABLE IF NOT EXISTS settlements (bet_id INTEGER PRIMARY KEY, status INTEGER NOT NULL, payout INTEGER NOT NULL, settled_time DATETIME NOT NULL);
@@ -0,0 +1 @@
+DROP INDEX bets_customer_id_placed_time;
@@ -0,0 +1,2 @@
+-- Bets are listed a customer at a time, most recently placed first.
+CREATE INDEX bets_customer_id_placed_time ON bets (customer_id, placed_time DESC, id DESC);
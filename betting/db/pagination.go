@@ -0,0 +1,98 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrInvalidPageSize is returned when a negative page size is requested.
+	ErrInvalidPageSize = errors.New("invalid page_size")
+
+	// ErrInvalidPageToken is returned when a page token can't be decoded, or was issued for a different filter.
+	ErrInvalidPageToken = errors.New("invalid page_token")
+)
+
+// maxPageSize caps the number of bets returned in a single page.
+const maxPageSize = 1000
+
+// pageCursor is the keyset cursor carried in page tokens. It records the sort key of the last bet
+// returned, so the next page starts strictly after it regardless of bets placed in the meantime.
+type pageCursor struct {
+	// Filter is a fingerprint of the filter the cursor was built for.
+	Filter string `json:"f"`
+	// PlacedTime is the last bet's placed time, as it's stored.
+	PlacedTime string `json:"t"`
+	// ID is the last bet's ID.
+	ID int64 `json:"i"`
+}
+
+// pageSize validates the requested page size, returning zero when the results shouldn't be paginated.
+func pageSize(size int32) (int, error) {
+	if size < 0 {
+		return 0, fmt.Errorf("%w: must not be negative", ErrInvalidPageSize)
+	}
+
+	if size > maxPageSize {
+		return maxPageSize, nil
+	}
+
+	return int(size), nil
+}
+
+// filterFingerprint identifies a filter, so a token can't be replayed against a different one.
+func filterFingerprint(filter *betting.ListBetsRequestFilter) (string, error) {
+	if filter == nil {
+		filter = &betting.ListBetsRequestFilter{}
+	}
+
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// encodePageToken builds an opaque page token pointing just past the given bet.
+func encodePageToken(fingerprint string, last *betting.Bet) (string, error) {
+	b, err := json.Marshal(pageCursor{
+		Filter:     fingerprint,
+		PlacedTime: last.PlacedTime.AsTime().UTC().Format(time.RFC3339),
+		ID:         last.Id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodePageToken parses a page token, checking it was issued for the same filter.
+func decodePageToken(token, fingerprint string) (*pageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidPageToken)
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidPageToken)
+	}
+
+	if cursor.Filter != fingerprint {
+		return nil, fmt.Errorf("%w: token does not match the request", ErrInvalidPageToken)
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,30 @@
+package db
+
+const (
+	betsList = "list"
+)
+
+func getBetQueries() map[string]string {
+	return map[string]string{
+		betsList: `
+			SELECT 
+				bets.id, 
+				bets.customer_id, 
+				bets.race_id, 
+				bets.runner_id, 
+				bets.type, 
+				bets.stake, 
+				bets.win_price, 
+				bets.place_price, 
+				bets.placed_time, 
+				` + betStatusExpr + ` AS status, 
+				COALESCE(settlements.payout, 0) AS payout, 
+				settlements.settled_time 
+			FROM bets 
+			LEFT JOIN settlements ON settlements.bet_id = bets.id
+		`,
+	}
+}
+
+// betStatusExpr is the status of a bet: its settled status once it's settled.
+const betStatusExpr = "COALESCE(settlements.status, bets.status)"
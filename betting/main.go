@@ -0,0 +1,204 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"git.neds.sh/matty/entain/betting/db"
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+	"git.neds.sh/matty/entain/betting/service"
+	"git.neds.sh/matty/entain/betting/settlement"
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/certs"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+var (
+	grpcEndpoint   = flag.String("grpc-endpoint", "localhost:9002", "gRPC server endpoint")
+	racingEndpoint = flag.String("racing-grpc-endpoint", "localhost:9000", "Racing gRPC server endpoint")
+	dbPath         = flag.String("db-path", "./db/betting.db", "path of the betting SQLite database")
+	settleRetry    = flag.Duration("settle-retry", 5*time.Second, "how long to wait before watching for resulted races again after a failure")
+	racingTLSCA    = flag.String("racing-tls-ca", "", "PEM file of the CAs trusted to sign the racing service's certificate, connecting to it over TLS")
+	racingTLSCert  = flag.String("racing-tls-cert", "", "PEM client certificate file to present to the racing service, along with -racing-tls-key, connecting to it over TLS")
+	racingTLSKey   = flag.String("racing-tls-key", "", "PEM private key file of -racing-tls-cert")
+	racingTLSName  = flag.String("racing-tls-server-name", "", "name the racing service's certificate must be valid for, by default the host of -racing-grpc-endpoint")
+	tlsReload      = flag.Duration("tls-reload-interval", 10*time.Second, "how often the TLS certificate files are checked for changes")
+	racingToken    = flag.String("racing-token", "", "bearer token to call the racing service with, needing the trader role to settle bets on invisible races")
+	jwksPath       = flag.String("jwks", "", "JSON Web Key Set file of the keys trusted to sign customers' bearer tokens; without one, every RPC is refused")
+)
+
+func main() {
+	flag.Parse()
+
+	if flag.Arg(0) == "migrate" {
+		if err := migrate(flag.Args()[1:]); err != nil {
+			log.Fatalf("failed migrating database: %s\n", err)
+		}
+
+		return
+	}
+
+	if err := run(); err != nil {
+		log.Fatalf("failed running grpc server: %s\n", err)
+	}
+}
+
+// migrate runs the migrate subcommand: "up" applies all pending migrations, "down [n]" reverts the
+// last n (default 1), and "status" lists the migrations and when they were applied.
+func migrate(args []string) error {
+	bettingDB, err := sql.Open("sqlite3", *dbPath)
+	if err != nil {
+		return err
+	}
+	defer bettingDB.Close()
+
+	migrator, err := db.NewMigrator(bettingDB)
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			log.Printf("applied migration %d_%s\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations to revert %q", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			log.Printf("reverted migration %d_%s\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			applied := "pending"
+			if !status.AppliedAt.IsZero() {
+				applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %q, want up, down or status", command)
+}
+
+func run() error {
+	conn, err := net.Listen("tcp", *grpcEndpoint)
+	if err != nil {
+		return err
+	}
+
+	bettingDB, err := sql.Open("sqlite3", *dbPath)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to serve from a schema the repository doesn't expect.
+	migrator, err := db.NewMigrator(bettingDB)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Check(); err != nil {
+		if errors.Is(err, db.ErrSchemaBehind) {
+			return fmt.Errorf("%w, run `betting migrate up` first", err)
+		}
+
+		return err
+	}
+
+	betsRepo := db.NewBetsRepo(bettingDB)
+
+	// Bets are checked against the races, runners and prices of the racing service.
+	transport, err := certs.DialOption(*racingTLSCA, *racingTLSCert, *racingTLSKey, *racingTLSName, *racingEndpoint, *tlsReload)
+	if err != nil {
+		return err
+	}
+
+	dialOpts := []grpc.DialOption{transport}
+	if *racingToken != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerToken(*racingToken)))
+	}
+
+	racingConn, err := grpc.Dial(*racingEndpoint, dialOpts...)
+	if err != nil {
+		return err
+	}
+	defer racingConn.Close()
+
+	racingClient := racing.NewRacingClient(racingConn)
+
+	// Bets are settled in the background, as their races are officially resulted.
+	go settlement.NewEngine(racingClient, betsRepo, *settleRetry).Run(context.Background())
+
+	// Customers are the subjects of the bearer tokens they call with, and only ever see their own bets.
+	var verifier *auth.Verifier
+	if *jwksPath != "" {
+		if verifier, err = auth.LoadVerifier(*jwksPath); err != nil {
+			return err
+		}
+	} else {
+		log.Printf("no -jwks given, every RPC is refused\n")
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryServerInterceptor(verifier, nil)))
+
+	betting.RegisterBettingServer(
+		grpcServer,
+		service.NewBettingService(
+			betsRepo,
+			racingClient,
+		),
+	)
+
+	log.Printf("gRPC server listening on: %s\n", *grpcEndpoint)
+
+	if err := grpcServer.Serve(conn); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// bearerToken authenticates calls with a JWT bearer token.
+type bearerToken string
+
+func (t bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity allows the token to be sent over the plaintext connection to the racing service.
+func (t bearerToken) RequireTransportSecurity() bool {
+	return false
+}
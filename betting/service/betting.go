@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+
+	"git.neds.sh/matty/entain/betting/db"
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+	"git.neds.sh/matty/entain/racing/auth"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultPageSize is the number of bets listed by ListBets when no page size is given.
+const defaultPageSize = 100
+
+// Betting serves the bets of the customers calling it, who are the subjects of the bearer tokens verified
+// by auth.UnaryServerInterceptor. Other customers' bets are never found.
+type Betting interface {
+	// PlaceBet will place a bet for the caller on a runner of an open race.
+	PlaceBet(ctx context.Context, in *betting.PlaceBetRequest) (*betting.Bet, error)
+
+	// GetBet will return a single bet of the caller's by its ID.
+	GetBet(ctx context.Context, in *betting.GetBetRequest) (*betting.Bet, error)
+
+	// ListBets will return a page of the caller's bets.
+	ListBets(ctx context.Context, in *betting.ListBetsRequest) (*betting.ListBetsResponse, error)
+}
+
+// bettingService implements the Betting interface.
+type bettingService struct {
+	betsRepo db.BetsRepo
+	racing   racing.RacingClient
+}
+
+// NewBettingService instantiates and returns a new bettingService, which checks bets against the races
+// served by the racing client.
+func NewBettingService(betsRepo db.BetsRepo, racingClient racing.RacingClient) Betting {
+	return &bettingService{betsRepo: betsRepo, racing: racingClient}
+}
+
+func (s *bettingService) PlaceBet(ctx context.Context, in *betting.PlaceBetRequest) (*betting.Bet, error) {
+	customerID, err := customerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBet(in); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	race, err := s.racing.GetRace(ctx, &racing.GetRaceRequest{Id: in.RaceId, IncludeRunners: true})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "race %d not found", in.RaceId)
+		}
+
+		return nil, status.Errorf(codes.Unavailable, "fetching race %d: %s", in.RaceId, status.Convert(err).Message())
+	}
+
+	price, err := priceBet(in, race)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.betsRepo.Create(&betting.Bet{
+		CustomerId: customerID,
+		RaceId:     in.RaceId,
+		RunnerId:   in.RunnerId,
+		Type:       in.Type,
+		Stake:      in.Stake,
+		WinPrice:   price.win,
+		PlacePrice: price.place,
+		PlacedTime: timestamppb.Now(),
+		Status:     betting.Bet_PENDING,
+	})
+}
+
+func (s *bettingService) GetBet(ctx context.Context, in *betting.GetBetRequest) (*betting.Bet, error) {
+	customerID, err := customerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bet, err := s.betsRepo.Get(in.Id)
+	if errors.Is(err, db.ErrBetNotFound) || (err == nil && bet.CustomerId != customerID) {
+		return nil, status.Errorf(codes.NotFound, "bet %d not found", in.Id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bet, nil
+}
+
+func (s *bettingService) ListBets(ctx context.Context, in *betting.ListBetsRequest) (*betting.ListBetsResponse, error) {
+	customerID, err := customerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bets are only ever listed a customer at a time, the caller, and a page at a time.
+	if in.GetFilter().GetCustomerId() != "" && in.GetFilter().GetCustomerId() != customerID {
+		return nil, status.Errorf(codes.NotFound, "customer %q not found", in.GetFilter().GetCustomerId())
+	}
+
+	in = proto.Clone(in).(*betting.ListBetsRequest)
+	if in.Filter == nil {
+		in.Filter = &betting.ListBetsRequestFilter{}
+	}
+
+	in.Filter.CustomerId = customerID
+
+	if in.PageSize == 0 {
+		in.PageSize = defaultPageSize
+	}
+
+	bets, nextPageToken, err := s.betsRepo.List(in)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidPageSize) || errors.Is(err, db.ErrInvalidPageToken) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, err
+	}
+
+	return &betting.ListBetsResponse{Bets: bets, NextPageToken: nextPageToken}, nil
+}
+
+// customerOf returns the ID of the customer calling, the subject of their verified bearer token.
+func customerOf(ctx context.Context) (string, error) {
+	claims, ok := auth.FromContext(ctx)
+	if !ok || claims.Subject == "" {
+		return "", status.Error(codes.Unauthenticated, "a bearer token naming the customer is required")
+	}
+
+	return claims.Subject, nil
+}
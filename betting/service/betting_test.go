@@ -0,0 +1,176 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"git.neds.sh/matty/entain/betting/db"
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+	"git.neds.sh/matty/entain/racing/auth"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBettingService_PlaceBet(t *testing.T) {
+	s := createService(t)
+	alice := customerContext("alice")
+
+	bet, err := s.PlaceBet(alice, &betting.PlaceBetRequest{
+		RaceId:   1,
+		RunnerId: 1,
+		Type:     betting.Bet_EACH_WAY,
+		Stake:    500,
+		WinPrice: 5.5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", bet.CustomerId)
+	assert.Equal(t, 5.5, bet.WinPrice)
+	assert.Equal(t, 2.1, bet.PlacePrice)
+	assert.Equal(t, betting.Bet_PENDING, bet.Status)
+
+	got, err := s.GetBet(alice, &betting.GetBetRequest{Id: bet.Id})
+	require.NoError(t, err)
+	assert.Equal(t, bet.Id, got.Id)
+
+	// Win bets are struck at the win price only.
+	bet, err = s.PlaceBet(alice, &betting.PlaceBetRequest{RaceId: 1, RunnerId: 2, Type: betting.Bet_WIN, Stake: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, bet.WinPrice)
+	assert.Zero(t, bet.PlacePrice)
+
+	bets, err := s.ListBets(alice, &betting.ListBetsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, bets.Bets, 2)
+
+	bets, err = s.ListBets(alice, &betting.ListBetsRequest{Filter: &betting.ListBetsRequestFilter{CustomerId: "alice"}})
+	require.NoError(t, err)
+	assert.Len(t, bets.Bets, 2)
+
+	_, err = s.ListBets(alice, &betting.ListBetsRequest{PageToken: "not a token"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.GetBet(alice, &betting.GetBetRequest{Id: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestBettingService_OtherCustomers(t *testing.T) {
+	s := createService(t)
+
+	bet, err := s.PlaceBet(customerContext("alice"), &betting.PlaceBetRequest{RaceId: 1, RunnerId: 2, Type: betting.Bet_WIN, Stake: 100})
+	require.NoError(t, err)
+
+	// Other customers' bets are never found...
+	bob := customerContext("bob")
+
+	_, err = s.GetBet(bob, &betting.GetBetRequest{Id: bet.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.ListBets(bob, &betting.ListBetsRequest{Filter: &betting.ListBetsRequestFilter{CustomerId: "alice"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	bets, err := s.ListBets(bob, &betting.ListBetsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, bets.Bets)
+
+	// ... and anonymous callers can't do anything.
+	_, err = s.PlaceBet(context.Background(), &betting.PlaceBetRequest{RaceId: 1, RunnerId: 2, Type: betting.Bet_WIN, Stake: 100})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = s.GetBet(context.Background(), &betting.GetBetRequest{Id: bet.Id})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = s.ListBets(context.Background(), &betting.ListBetsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestBettingService_PlaceBetRejected(t *testing.T) {
+	s := createService(t)
+
+	bet := func(raceID, runnerID int64, betType betting.Bet_Type) *betting.PlaceBetRequest {
+		return &betting.PlaceBetRequest{RaceId: raceID, RunnerId: runnerID, Type: betType, Stake: 100}
+	}
+
+	tests := []struct {
+		name string
+		in   *betting.PlaceBetRequest
+		code codes.Code
+	}{
+		{"no type", bet(1, 1, betting.Bet_TYPE_UNSPECIFIED), codes.InvalidArgument},
+		{"unknown type", bet(1, 1, betting.Bet_Type(7)), codes.InvalidArgument},
+		{"no stake", &betting.PlaceBetRequest{RaceId: 1, RunnerId: 1, Type: betting.Bet_WIN}, codes.InvalidArgument},
+		{"unknown race", bet(1000, 1, betting.Bet_WIN), codes.NotFound},
+		{"closed race", bet(2, 4, betting.Bet_WIN), codes.FailedPrecondition},
+		{"invisible race", bet(3, 5, betting.Bet_WIN), codes.FailedPrecondition},
+		{"unknown runner", bet(1, 9, betting.Bet_WIN), codes.InvalidArgument},
+		{"scratched runner", bet(1, 3, betting.Bet_WIN), codes.FailedPrecondition},
+		{"no place price", bet(1, 2, betting.Bet_PLACE), codes.FailedPrecondition},
+		{"win price moved", &betting.PlaceBetRequest{RaceId: 1, RunnerId: 1, Type: betting.Bet_WIN, Stake: 100, WinPrice: 6}, codes.Aborted},
+		{"racing unavailable", bet(4, 1, betting.Bet_WIN), codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.PlaceBet(customerContext("alice"), tt.in)
+			assert.Equal(t, tt.code, status.Code(err), err)
+		})
+	}
+}
+
+// fakeRacing serves races to the betting service in place of the racing service.
+type fakeRacing struct {
+	racing.RacingClient
+	races map[int64]*racing.Race
+}
+
+func (f *fakeRacing) GetRace(ctx context.Context, in *racing.GetRaceRequest, opts ...grpc.CallOption) (*racing.Race, error) {
+	if in.Id == 4 {
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+
+	race, ok := f.races[in.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "race %d not found", in.Id)
+	}
+
+	return race, nil
+}
+
+// customerContext returns the context of a call authenticated as a customer.
+func customerContext(customerID string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: customerID}})
+}
+
+func createService(t *testing.T) Betting {
+	bettingDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	// Keep the in-memory database alive across the pool's connections.
+	bettingDB.SetMaxOpenConns(1)
+
+	migrator, err := db.NewMigrator(bettingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	betsRepo := db.NewBetsRepo(bettingDB)
+
+	return NewBettingService(betsRepo, &fakeRacing{races: map[int64]*racing.Race{
+		1: {Id: 1, Visible: true, Status: racing.Race_OPEN, Runners: []*racing.Runner{
+			{Id: 1, RaceId: 1, Price: &racing.Price{Win: 5.5, Place: 2.1}},
+			{Id: 2, RaceId: 1, Price: &racing.Price{Win: 3}},
+			{Id: 3, RaceId: 1, Scratched: true},
+		}},
+		2: {Id: 2, Visible: true, Status: racing.Race_CLOSED, Runners: []*racing.Runner{
+			{Id: 4, RaceId: 2, Price: &racing.Price{Win: 2, Place: 1.2}},
+		}},
+		3: {Id: 3, Status: racing.Race_OPEN, Runners: []*racing.Runner{
+			{Id: 5, RaceId: 3, Price: &racing.Price{Win: 2, Place: 1.2}},
+		}},
+	}})
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxStake caps the stake of a single bet, in cents.
+const maxStake = 100000000
+
+// struckPrice is the price a bet is struck at, with only the prices of the bet's parts set.
+type struckPrice struct {
+	win, place float64
+}
+
+// validateBet checks the fields of a bet that don't depend on its race.
+func validateBet(in *betting.PlaceBetRequest) error {
+	switch in.Type {
+	case betting.Bet_WIN, betting.Bet_PLACE, betting.Bet_EACH_WAY:
+	case betting.Bet_TYPE_UNSPECIFIED:
+		return errors.New("type must be specified")
+	default:
+		return fmt.Errorf("unknown type %d", in.Type)
+	}
+
+	switch {
+	case in.Stake <= 0:
+		return errors.New("stake must be positive")
+	case in.Stake > maxStake:
+		return errors.New("stake is over the maximum stake")
+	}
+
+	return nil
+}
+
+// priceBet checks a bet can be placed on its race and runner, returning the price it's struck at: the
+// runner's current price, which must match any price the customer expects.
+func priceBet(in *betting.PlaceBetRequest, race *racing.Race) (struckPrice, error) {
+	if !race.Visible {
+		return struckPrice{}, status.Errorf(codes.FailedPrecondition, "race %d is not visible", race.Id)
+	}
+
+	if race.Status != racing.Race_OPEN {
+		return struckPrice{}, status.Errorf(codes.FailedPrecondition, "race %d is closed", race.Id)
+	}
+
+	var runner *racing.Runner
+	for _, r := range race.Runners {
+		if r.Id == in.RunnerId {
+			runner = r
+		}
+	}
+
+	if runner == nil {
+		return struckPrice{}, status.Errorf(codes.InvalidArgument, "runner %d is not running in race %d", in.RunnerId, race.Id)
+	}
+
+	if runner.Scratched {
+		return struckPrice{}, status.Errorf(codes.FailedPrecondition, "runner %d is scratched", runner.Id)
+	}
+
+	var price struckPrice
+
+	if in.Type == betting.Bet_WIN || in.Type == betting.Bet_EACH_WAY {
+		if runner.Price.GetWin() == 0 {
+			return struckPrice{}, status.Errorf(codes.FailedPrecondition, "runner %d has no win price", runner.Id)
+		}
+
+		if in.WinPrice != 0 && in.WinPrice != runner.Price.Win {
+			return struckPrice{}, status.Errorf(codes.Aborted, "win price of runner %d has moved to %.2f", runner.Id, runner.Price.Win)
+		}
+
+		price.win = runner.Price.Win
+	}
+
+	if in.Type == betting.Bet_PLACE || in.Type == betting.Bet_EACH_WAY {
+		if runner.Price.GetPlace() == 0 {
+			return struckPrice{}, status.Errorf(codes.FailedPrecondition, "runner %d has no place price", runner.Id)
+		}
+
+		if in.PlacePrice != 0 && in.PlacePrice != runner.Price.Place {
+			return struckPrice{}, status.Errorf(codes.Aborted, "place price of runner %d has moved to %.2f", runner.Id, runner.Price.Place)
+		}
+
+		price.place = runner.Price.Place
+	}
+
+	return price, nil
+}
@@ -0,0 +1,108 @@
+package settlement
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"git.neds.sh/matty/entain/betting/db"
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Engine settles the pending bets on races as the racing service results them.
+type Engine struct {
+	racing   racing.RacingClient
+	betsRepo db.BetsRepo
+	retry    time.Duration
+}
+
+// NewEngine creates a settlement engine, watching the racing client for resulted races. When the watch
+// fails, it's retried after the retry interval.
+func NewEngine(racingClient racing.RacingClient, betsRepo db.BetsRepo, retry time.Duration) *Engine {
+	return &Engine{racing: racingClient, betsRepo: betsRepo, retry: retry}
+}
+
+// Run settles bets until the context is done. Races resulted while the engine wasn't running are caught
+// up on from the watch's snapshot.
+func (e *Engine) Run(ctx context.Context) error {
+	for {
+		if err := e.watch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("failed watching resulted races: %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(e.retry):
+		}
+	}
+}
+
+func (e *Engine) watch(ctx context.Context) error {
+	resulted := racing.Race_RESULTED
+
+	stream, err := e.racing.WatchRaces(ctx, &racing.ListRacesRequestFilter{Status: &resulted})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if event.Type != racing.RaceEvent_SNAPSHOT && event.Type != racing.RaceEvent_RESULTED {
+			continue
+		}
+
+		// A race that fails to settle is settled again when the watch is next established.
+		if _, err := e.SettleRace(ctx, event.Race.Id); err != nil {
+			log.Printf("failed settling race %d: %s\n", event.Race.Id, err)
+		}
+	}
+}
+
+// SettleRace settles the pending bets on a race with an official result, returning the number of bets
+// settled. Bets are only ever settled once, so settling a race again is a no-op.
+func (e *Engine) SettleRace(ctx context.Context, raceID int64) (int, error) {
+	raceResult, err := e.racing.GetRaceResults(ctx, &racing.GetRaceResultsRequest{RaceId: raceID})
+	if err != nil {
+		return 0, fmt.Errorf("fetching result: %w", err)
+	}
+
+	if raceResult.Status != racing.RaceResult_OFFICIAL {
+		return 0, nil
+	}
+
+	runners, err := e.racing.ListRunners(ctx, &racing.ListRunnersRequest{RaceId: raceID})
+	if err != nil {
+		return 0, fmt.Errorf("fetching runners: %w", err)
+	}
+
+	bets, _, err := e.betsRepo.List(&betting.ListBetsRequest{Filter: &betting.ListBetsRequestFilter{
+		RaceIds:  []int64{raceID},
+		Statuses: []betting.Bet_Status{betting.Bet_PENDING},
+	}})
+	if err != nil {
+		return 0, err
+	}
+
+	result := NewResult(raceResult.Placings, runners.Runners)
+	settledTime := timestamppb.Now()
+
+	for _, bet := range bets {
+		bet.Status, bet.Payout = Settle(bet, result)
+		bet.SettledTime = settledTime
+	}
+
+	return e.betsRepo.Settle(bets)
+}
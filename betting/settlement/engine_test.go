@@ -0,0 +1,170 @@
+package settlement
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/betting/db"
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestEngine_SettleRace(t *testing.T) {
+	betsRepo := createRepo(t)
+	engine := NewEngine(newFakeRacing(), betsRepo, time.Millisecond)
+
+	winner := placeBet(t, betsRepo, 1, win(1, 3))
+	loser := placeBet(t, betsRepo, 1, eachWay(4, 8, 2.5))
+	refunded := placeBet(t, betsRepo, 1, place(9, 2))
+	unresulted := placeBet(t, betsRepo, 2, win(1, 3))
+
+	settled, err := engine.SettleRace(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, settled)
+
+	assertSettled(t, betsRepo, winner, betting.Bet_WON, 300)
+	assertSettled(t, betsRepo, loser, betting.Bet_LOST, 0)
+	assertSettled(t, betsRepo, refunded, betting.Bet_REFUNDED, 100)
+
+	// Settling a race again doesn't settle its bets twice.
+	settled, err = engine.SettleRace(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Zero(t, settled)
+
+	// Interim results aren't settled on.
+	settled, err = engine.SettleRace(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Zero(t, settled)
+	assertSettled(t, betsRepo, unresulted, betting.Bet_PENDING, 0)
+
+	// Races without a result can't be settled.
+	_, err = engine.SettleRace(context.Background(), 3)
+	assert.Error(t, err)
+}
+
+func TestEngine_Run(t *testing.T) {
+	betsRepo := createRepo(t)
+	engine := NewEngine(newFakeRacing(), betsRepo, time.Millisecond)
+
+	bet := placeBet(t, betsRepo, 1, win(1, 3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- engine.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		got, err := betsRepo.Get(bet.Id)
+		return err == nil && got.Status == betting.Bet_WON
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func assertSettled(t *testing.T, betsRepo db.BetsRepo, bet *betting.Bet, status betting.Bet_Status, payout int64) {
+	got, err := betsRepo.Get(bet.Id)
+	require.NoError(t, err)
+	assert.Equal(t, status, got.Status)
+	assert.Equal(t, payout, got.Payout)
+	assert.Equal(t, status != betting.Bet_PENDING, got.SettledTime != nil)
+}
+
+func placeBet(t *testing.T, betsRepo db.BetsRepo, raceID int64, bet *betting.Bet) *betting.Bet {
+	bet.CustomerId = "alice"
+	bet.RaceId = raceID
+	bet.PlacedTime = timestamppb.Now()
+	bet.Status = betting.Bet_PENDING
+
+	placed, err := betsRepo.Create(bet)
+	require.NoError(t, err)
+
+	return placed
+}
+
+// fakeRacing serves the results and runners of races to the engine in place of the racing service.
+// Race 1 is officially resulted, and race 2 only has an interim result.
+type fakeRacing struct {
+	racing.RacingClient
+	results map[int64]*racing.RaceResult
+	runners []*racing.Runner
+}
+
+func newFakeRacing() *fakeRacing {
+	return &fakeRacing{
+		results: map[int64]*racing.RaceResult{
+			1: {RaceId: 1, Status: racing.RaceResult_OFFICIAL, Placings: []*racing.Placing{
+				{RunnerId: 1, Position: 1},
+				{RunnerId: 2, Position: 2},
+				{RunnerId: 3, Position: 3},
+			}},
+			2: {RaceId: 2, Status: racing.RaceResult_INTERIM, Placings: []*racing.Placing{
+				{RunnerId: 1, Position: 1},
+			}},
+		},
+		runners: []*racing.Runner{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}, {Id: 5}, {Id: 6}, {Id: 7}, {Id: 8}, {Id: 9, Scratched: true}},
+	}
+}
+
+func (f *fakeRacing) GetRaceResults(ctx context.Context, in *racing.GetRaceResultsRequest, opts ...grpc.CallOption) (*racing.RaceResult, error) {
+	result, ok := f.results[in.RaceId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "race %d has not been resulted", in.RaceId)
+	}
+
+	return result, nil
+}
+
+func (f *fakeRacing) ListRunners(ctx context.Context, in *racing.ListRunnersRequest, opts ...grpc.CallOption) (*racing.ListRunnersResponse, error) {
+	return &racing.ListRunnersResponse{Runners: f.runners}, nil
+}
+
+func (f *fakeRacing) WatchRaces(ctx context.Context, in *racing.ListRacesRequestFilter, opts ...grpc.CallOption) (racing.Racing_WatchRacesClient, error) {
+	return &fakeWatch{ctx: ctx, events: []*racing.RaceEvent{
+		{Type: racing.RaceEvent_SNAPSHOT, Race: &racing.Race{Id: 2, Status: racing.Race_CLOSED}},
+		{Type: racing.RaceEvent_RESULTED, Race: &racing.Race{Id: 1, Status: racing.Race_RESULTED}},
+	}}, nil
+}
+
+// fakeWatch streams its events, then blocks until the watch is cancelled.
+type fakeWatch struct {
+	grpc.ClientStream
+	ctx    context.Context
+	events []*racing.RaceEvent
+}
+
+func (w *fakeWatch) Recv() (*racing.RaceEvent, error) {
+	if len(w.events) == 0 {
+		<-w.ctx.Done()
+		return nil, status.FromContextError(w.ctx.Err()).Err()
+	}
+
+	event := w.events[0]
+	w.events = w.events[1:]
+
+	return event, nil
+}
+
+func createRepo(t *testing.T) db.BetsRepo {
+	bettingDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	// Keep the in-memory database alive across the pool's connections.
+	bettingDB.SetMaxOpenConns(1)
+
+	migrator, err := db.NewMigrator(bettingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	repo := db.NewBetsRepo(bettingDB)
+
+	return repo
+}
@@ -0,0 +1,136 @@
+// Package settlement settles bets once their races are officially resulted.
+package settlement
+
+import (
+	"math"
+
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+)
+
+// Result is the outcome of a race that bets on it are settled against.
+type Result struct {
+	// Placings are the official finishing positions of the placed runners.
+	Placings []*racing.Placing
+	// Scratched is the set of runners withdrawn from the race, whose bets are refunded.
+	Scratched map[int64]bool
+	// Starters is the number of runners that ran, which decides how many places are paid.
+	Starters int
+}
+
+// NewResult builds the result of a race from its official placings and its field of runners.
+func NewResult(placings []*racing.Placing, runners []*racing.Runner) Result {
+	result := Result{Placings: placings, Scratched: map[int64]bool{}}
+
+	for _, runner := range runners {
+		if runner.Scratched {
+			result.Scratched[runner.Id] = true
+		} else {
+			result.Starters++
+		}
+	}
+
+	return result
+}
+
+// PlacesPaid is the number of places place bets are paid on, by the number of starters: none for fields
+// of up to 4 runners, 2 for up to 7 runners, and 3 for larger fields.
+func PlacesPaid(starters int) int {
+	switch {
+	case starters <= 4:
+		return 0
+	case starters <= 7:
+		return 2
+	}
+
+	return 3
+}
+
+// Settle works out the status and payout of a bet, in cents. Each-way bets are settled as a win bet and
+// a place bet of the same stake.
+//
+// Bets on scratched runners are refunded, as are place bets when too few runners start for places to
+// be paid. When runners dead heat across the paid places, the stake is divided between them, in
+// proportion to the paid places they share: e.g. half the stake is paid out on each of two runners dead
+// heating for first at the win price.
+func Settle(bet *betting.Bet, result Result) (betting.Bet_Status, int64) {
+	var parts []part
+
+	if bet.Type == betting.Bet_WIN || bet.Type == betting.Bet_EACH_WAY {
+		parts = append(parts, settlePart(bet, result, 1, bet.WinPrice))
+	}
+
+	if bet.Type == betting.Bet_PLACE || bet.Type == betting.Bet_EACH_WAY {
+		parts = append(parts, settlePart(bet, result, PlacesPaid(result.Starters), bet.PlacePrice))
+	}
+
+	var (
+		payout   float64
+		won      bool
+		refunded = len(parts) > 0
+	)
+
+	for _, p := range parts {
+		payout += p.payout
+		won = won || p.won
+		refunded = refunded && p.refunded
+	}
+
+	// Payouts are rounded down to the cent, allowing for floating point error, e.g. 100 * 1.15.
+	cents := int64(math.Floor(payout + 1e-6))
+
+	switch {
+	case won:
+		return betting.Bet_WON, cents
+	case refunded:
+		return betting.Bet_REFUNDED, cents
+	}
+
+	return betting.Bet_LOST, cents
+}
+
+// part is the outcome of the win or place part of a bet.
+type part struct {
+	payout   float64
+	won      bool
+	refunded bool
+}
+
+// settlePart settles a part of a bet paying out at price when the runner finishes within places.
+func settlePart(bet *betting.Bet, result Result, places int, price float64) part {
+	stake := float64(bet.Stake)
+
+	if result.Scratched[bet.RunnerId] || places == 0 {
+		return part{payout: stake, refunded: true}
+	}
+
+	var (
+		position int64
+		sharing  int64
+	)
+
+	for _, placing := range result.Placings {
+		if placing.RunnerId == bet.RunnerId {
+			position = placing.Position
+		}
+	}
+
+	if position == 0 || position > int64(places) {
+		return part{}
+	}
+
+	for _, placing := range result.Placings {
+		if placing.Position == position {
+			sharing++
+		}
+	}
+
+	// Runners dead heating take up as many positions as there are of them, e.g. two runners dead
+	// heating for third take up third and fourth, of which only third is paid.
+	paid := sharing
+	if last := position + sharing - 1; last > int64(places) {
+		paid -= last - int64(places)
+	}
+
+	return part{payout: stake * price * float64(paid) / float64(sharing), won: true}
+}
@@ -0,0 +1,87 @@
+package settlement
+
+import (
+	"testing"
+
+	"git.neds.sh/matty/entain/betting/proto/betting"
+	"git.neds.sh/matty/entain/betting/proto/racing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettle(t *testing.T) {
+	// placings builds placings from pairs of runner IDs and positions.
+	placings := func(runnerPositions ...int64) []*racing.Placing {
+		var placings []*racing.Placing
+		for i := 0; i < len(runnerPositions); i += 2 {
+			placings = append(placings, &racing.Placing{RunnerId: runnerPositions[i], Position: runnerPositions[i+1]})
+		}
+		return placings
+	}
+
+	straight := placings(1, 1, 2, 2, 3, 3)
+	scratched := map[int64]bool{9: true}
+
+	tests := []struct {
+		name   string
+		bet    *betting.Bet
+		result Result
+		status betting.Bet_Status
+		payout int64
+	}{
+		{"win winner", win(1, 5.5), Result{straight, nil, 8}, betting.Bet_WON, 550},
+		{"win second", win(2, 5.5), Result{straight, nil, 8}, betting.Bet_LOST, 0},
+		{"win unplaced", win(4, 5.5), Result{straight, nil, 8}, betting.Bet_LOST, 0},
+		{"win rounded down", win(1, 1.15), Result{straight, nil, 8}, betting.Bet_WON, 115},
+		{"win scratched", win(9, 5.5), Result{straight, scratched, 8}, betting.Bet_REFUNDED, 100},
+		{"win dead heat of two", win(2, 4), Result{placings(1, 1, 2, 1, 3, 3), nil, 8}, betting.Bet_WON, 200},
+		{"win dead heat of three", win(3, 3), Result{placings(1, 1, 2, 1, 3, 1), nil, 8}, betting.Bet_WON, 100},
+		{"place third of eight", place(3, 2), Result{straight, nil, 8}, betting.Bet_WON, 200},
+		{"place third of six", place(3, 2), Result{straight, nil, 6}, betting.Bet_LOST, 0},
+		{"place second of five", place(2, 1.5), Result{straight, nil, 5}, betting.Bet_WON, 150},
+		{"place with four starters", place(1, 2), Result{straight, nil, 4}, betting.Bet_REFUNDED, 100},
+		{"place scratched", place(9, 2), Result{straight, scratched, 8}, betting.Bet_REFUNDED, 100},
+		{"place dead heat for second", place(3, 2), Result{placings(1, 1, 2, 2, 3, 2), nil, 8}, betting.Bet_WON, 200},
+		{"place dead heat for third", place(4, 2), Result{placings(1, 1, 2, 2, 3, 3, 4, 3), nil, 8}, betting.Bet_WON, 100},
+		{"place dead heat of three for third", place(4, 3), Result{placings(1, 1, 2, 2, 3, 3, 4, 3, 5, 3), nil, 8}, betting.Bet_WON, 100},
+		{"each-way winner", eachWay(1, 5, 2), Result{straight, nil, 8}, betting.Bet_WON, 700},
+		{"each-way third", eachWay(3, 5, 2), Result{straight, nil, 8}, betting.Bet_WON, 200},
+		{"each-way unplaced", eachWay(4, 5, 2), Result{straight, nil, 8}, betting.Bet_LOST, 0},
+		{"each-way scratched", eachWay(9, 5, 2), Result{straight, scratched, 8}, betting.Bet_REFUNDED, 200},
+		{"each-way winner of four starters", eachWay(1, 5, 2), Result{straight, nil, 4}, betting.Bet_WON, 600},
+		{"each-way loser of four starters", eachWay(2, 5, 2), Result{straight, nil, 4}, betting.Bet_LOST, 100},
+		{"each-way dead heat for first", eachWay(2, 6, 2.5), Result{placings(1, 1, 2, 1, 3, 3), nil, 8}, betting.Bet_WON, 550},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, payout := Settle(tt.bet, tt.result)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.payout, payout)
+		})
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	result := NewResult(nil, []*racing.Runner{{Id: 1}, {Id: 2, Scratched: true}, {Id: 3}})
+	assert.Equal(t, 2, result.Starters)
+	assert.Equal(t, map[int64]bool{2: true}, result.Scratched)
+}
+
+func TestPlacesPaid(t *testing.T) {
+	for starters, places := range map[int]int{2: 0, 4: 0, 5: 2, 7: 2, 8: 3, 16: 3} {
+		assert.Equalf(t, places, PlacesPaid(starters), "%d starters", starters)
+	}
+}
+
+// win, place and eachWay are bets of 100 cents on a runner.
+func win(runnerID int64, price float64) *betting.Bet {
+	return &betting.Bet{RunnerId: runnerID, Type: betting.Bet_WIN, Stake: 100, WinPrice: price}
+}
+
+func place(runnerID int64, price float64) *betting.Bet {
+	return &betting.Bet{RunnerId: runnerID, Type: betting.Bet_PLACE, Stake: 100, PlacePrice: price}
+}
+
+func eachWay(runnerID int64, winPrice, placePrice float64) *betting.Bet {
+	return &betting.Bet{RunnerId: runnerID, Type: betting.Bet_EACH_WAY, Stake: 100, WinPrice: winPrice, PlacePrice: placePrice}
+}
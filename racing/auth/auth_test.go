@@ -0,0 +1,164 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/auth/authtest"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	issuer := authtest.NewIssuer(t)
+	key, verifier := issuer.Key, issuer.Verifier(t)
+
+	claims, err := verifier.Verify(issuer.Token(t, "alice", "admin"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.True(t, claims.HasRole("trader", "admin"))
+	assert.False(t, claims.HasRole("trader"))
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	})
+	hmac.Header["kid"] = authtest.KeyID
+	hmacSigned, err := hmac.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"expired":        authtest.Sign(t, key, authtest.KeyID, "alice", -time.Minute, "admin"),
+		"no expiry":      authtest.Sign(t, key, authtest.KeyID, "alice", 0, "admin"),
+		"untrusted key":  authtest.Sign(t, otherKey, authtest.KeyID, "alice", time.Hour, "admin"),
+		"unknown key id": authtest.Sign(t, key, "other", "alice", time.Hour, "admin"),
+		"wrong key type": authtest.Sign(t, ecKey, authtest.KeyID, "alice", time.Hour, "admin"),
+		"unsigned":       unsigned,
+		"symmetric":      hmacSigned,
+		"malformed":      "not.a.token",
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(token)
+			assert.ErrorIs(t, err, auth.ErrInvalidToken)
+		})
+	}
+
+	_, err = verifier.VerifyAuthorization("Basic YWxpY2U6c2VjcmV0")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	_, err = verifier.VerifyAuthorization("bearer " + issuer.Token(t, "alice"))
+	assert.NoError(t, err)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	issuer := authtest.NewIssuer(t)
+	verifier := issuer.Verifier(t)
+	interceptor := auth.UnaryServerInterceptor(verifier, auth.Policy{"/test/Admin": {"admin"}})
+
+	call := func(method, token string) (*auth.Claims, error) {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+		}
+
+		resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			claims, _ := auth.FromContext(ctx)
+			return claims, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return resp.(*auth.Claims), nil
+	}
+
+	admin := issuer.Token(t, "alice", "admin")
+	retail := issuer.Token(t, "bob", "retail")
+
+	claims, err := call("/test/Public", "")
+	assert.NoError(t, err)
+	assert.Nil(t, claims, "Callers without a token are anonymous.")
+
+	claims, err = call("/test/Public", retail)
+	assert.NoError(t, err)
+	if assert.NotNil(t, claims) {
+		assert.Equal(t, "bob", claims.Subject)
+	}
+
+	_, err = call("/test/Public", "forged")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = call("/test/Admin", "")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = call("/test/Admin", retail)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	claims, err = call("/test/Admin", admin)
+	assert.NoError(t, err)
+	if assert.NotNil(t, claims) {
+		assert.Equal(t, "alice", claims.Subject)
+	}
+
+	// Without a key set, nobody can be authenticated.
+	_, err = auth.UnaryServerInterceptor(nil, auth.Policy{"/test/Admin": {"admin"}})(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+admin)),
+		nil,
+		&grpc.UnaryServerInfo{FullMethod: "/test/Admin"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil },
+	)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	issuer := authtest.NewIssuer(t)
+	verifier := issuer.Verifier(t)
+	interceptor := auth.StreamServerInterceptor(verifier, auth.Policy{"/test/Admin": {"admin"}})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+issuer.Token(t, "alice", "admin")))
+
+	var claims *auth.Claims
+	err := interceptor(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test/Admin"}, func(srv interface{}, stream grpc.ServerStream) error {
+		claims, _ = auth.FromContext(stream.Context())
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.NotNil(t, claims) {
+		assert.Equal(t, "alice", claims.Subject)
+	}
+
+	err = interceptor(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test/Admin"}, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,124 @@
+// Command devtoken signs bearer tokens for trying out the racing service locally. It keeps an RSA key in
+// a file, generating it if needed, writes the JSON Web Key Set trusting it for the racing service's
+// -jwks flag, and prints a token with the given subject and roles.
+//
+// The key is for development only: anyone holding it can sign tokens granting any role.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/auth"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// keyID names the development key in the key set and the tokens it signs.
+const keyID = "dev"
+
+var (
+	keyPath  = flag.String("key", "dev-key.pem", "PEM file of the RSA signing key, generated if it doesn't exist")
+	jwksPath = flag.String("jwks", "dev-jwks.json", "JSON Web Key Set file to write, trusting the signing key")
+	subject  = flag.String("sub", "dev", "subject of the token")
+	roles    = flag.String("roles", "", "comma separated roles of the token, e.g. admin")
+	ttl      = flag.Duration("ttl", 24*time.Hour, "how long the token is valid for")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatalf("failed signing token: %s\n", err)
+	}
+}
+
+func run() error {
+	key, err := loadKey(*keyPath)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJWKS(*jwksPath, &key.PublicKey); err != nil {
+		return err
+	}
+
+	claims := &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   *subject,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(*ttl)),
+		},
+	}
+
+	if *roles != "" {
+		claims.Roles = strings.Split(*roles, ",")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(signed)
+
+	return nil
+}
+
+// loadKey reads the RSA key of a PEM file, generating and writing one if the file doesn't exist.
+func loadKey(path string) (*rsa.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+
+		b = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+		return key, os.WriteFile(path, b, 0600)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: not a PEM file", path)
+	}
+
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+func writeJWKS(path string, key *rsa.PublicKey) error {
+	b, err := json.MarshalIndent(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Policy maps the full names of gRPC methods, e.g. "/racing.Racing/CreateRace", onto the roles allowed to
+// call them. Methods missing from the policy can be called by anyone, authenticated or not.
+type Policy map[string][]string
+
+// UnaryServerInterceptor authenticates the callers of unary RPCs by the bearer token in their
+// "authorization" metadata, and authorises them against the policy. The claims of authenticated callers
+// are added to the context, see FromContext.
+//
+// Callers without a token are anonymous. Invalid tokens are rejected, even for methods anyone can call. A
+// nil verifier verifies no tokens, so every caller is anonymous.
+func UnaryServerInterceptor(v *Verifier, policy Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := v.authorize(ctx, info.FullMethod, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates and authorises the callers of streaming RPCs, as
+// UnaryServerInterceptor does for unary RPCs.
+func StreamServerInterceptor(v *Verifier, policy Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.authorize(stream.Context(), info.FullMethod, policy)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authorizedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorize authenticates the caller of a method, returning a context carrying their claims, and checks
+// they're allowed to call it.
+func (v *Verifier) authorize(ctx context.Context, method string, policy Policy) (context.Context, error) {
+	var claims *Claims
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && v != nil && len(md.Get("authorization")) > 0 {
+		var err error
+		if claims, err = v.VerifyAuthorization(md.Get("authorization")[0]); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		ctx = NewContext(ctx, claims)
+	}
+
+	roles, restricted := policy[method]
+	if !restricted {
+		return ctx, nil
+	}
+
+	if claims == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s requires authentication", method)
+	}
+
+	if !claims.HasRole(roles...) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s requires one of the roles %s", method, strings.Join(roles, ", "))
+	}
+
+	return ctx, nil
+}
+
+// authorizedStream is a server stream whose context carries the caller's claims.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
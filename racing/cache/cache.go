@@ -0,0 +1,24 @@
+// Package cache provides stores for caching encoded values by key, either in process or shared through
+// Redis.
+package cache
+
+import "time"
+
+// Store holds cached values by key, until they expire or the store is invalidated.
+//
+// Invalidating moves the store on to a new generation. Values are cached as of the generation read before
+// they were computed, so a value computed while the store was invalidated is never found.
+type Store interface {
+	// Generation will return the store's current generation.
+	Generation() (int64, error)
+
+	// Get will return the value cached under key, and whether one was found. Expired values aren't found.
+	Get(key string) ([]byte, bool, error)
+
+	// Set will cache a value under key, for ttl, as of generation. Values of a generation the store has
+	// since moved on from are never found.
+	Set(generation int64, key string, value []byte, ttl time.Duration) error
+
+	// Invalidate will drop every cached value, moving the store on to a new generation.
+	Invalidate() error
+}
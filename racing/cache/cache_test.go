@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forEachStore runs a test against an empty store of each kind, along with a way of moving its clock on.
+func forEachStore(t *testing.T, test func(t *testing.T, store Store, advance func(time.Duration))) {
+	t.Run("lru", func(t *testing.T) {
+		now := time.Now()
+		store := NewLRUStore(10).(*lruStore)
+		store.now = func() time.Time { return now }
+
+		test(t, store, func(d time.Duration) { now = now.Add(d) })
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		server, err := miniredis.Run()
+		require.NoError(t, err)
+		t.Cleanup(server.Close)
+
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+
+		test(t, NewRedisStore(client, "test"), server.FastForward)
+	})
+}
+
+func TestStore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store, advance func(time.Duration)) {
+		_, ok, err := store.Get("a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, store.Set(0, "a", []byte("1"), time.Minute))
+		require.NoError(t, store.Set(0, "b", []byte("2"), 2*time.Minute))
+
+		value, ok, err := store.Get("a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), value)
+
+		// Values expire after their TTL.
+		advance(time.Minute)
+
+		_, ok, err = store.Get("a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, ok, err = store.Get("b")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		// Invalidating drops every value, but values set afterwards are cached again.
+		require.NoError(t, store.Invalidate())
+
+		_, ok, err = store.Get("b")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		generation, err := store.Generation()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), generation)
+
+		require.NoError(t, store.Set(generation, "b", []byte("3"), time.Minute))
+
+		value, ok, err = store.Get("b")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("3"), value)
+
+		// Values computed while the store was invalidated are never found.
+		require.NoError(t, store.Invalidate())
+		require.NoError(t, store.Set(generation, "c", []byte("4"), time.Minute))
+
+		_, ok, err = store.Get("c")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestLRUStore_Evicts(t *testing.T) {
+	store := NewLRUStore(2)
+
+	require.NoError(t, store.Set(0, "a", []byte("1"), time.Minute))
+	require.NoError(t, store.Set(0, "b", []byte("2"), time.Minute))
+
+	// Reading a makes b the least recently used, so it's evicted to make room for c.
+	_, ok, err := store.Get("a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, store.Set(0, "c", []byte("3"), time.Minute))
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		_, ok, err := store.Get(key)
+		require.NoError(t, err)
+		assert.Equalf(t, want, ok, "Key %s cached", key)
+	}
+}
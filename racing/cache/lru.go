@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry is a cached value, kept in the LRU's recency list.
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// lruStore is an in-process Store holding a bounded number of values, evicting the least recently used
+// when full.
+type lruStore struct {
+	mu         sync.Mutex
+	size       int
+	generation int64
+	entries    map[string]*list.Element
+	// recency orders the entries from most to least recently used.
+	recency *list.List
+	now     func() time.Time
+}
+
+// NewLRUStore creates an in-process store holding at most size values.
+func NewLRUStore(size int) Store {
+	return &lruStore{
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		recency: list.New(),
+		now:     time.Now,
+	}
+}
+
+func (s *lruStore) Generation() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.generation, nil
+}
+
+func (s *lruStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !s.now().Before(entry.expires) {
+		s.remove(element)
+		return nil, false, nil
+	}
+
+	s.recency.MoveToFront(element)
+
+	return entry.value, true, nil
+}
+
+func (s *lruStore) Set(generation int64, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size <= 0 || generation != s.generation {
+		return nil
+	}
+
+	entry := &lruEntry{key: key, value: value, expires: s.now().Add(ttl)}
+
+	if element, ok := s.entries[key]; ok {
+		element.Value = entry
+		s.recency.MoveToFront(element)
+
+		return nil
+	}
+
+	s.entries[key] = s.recency.PushFront(entry)
+
+	for s.recency.Len() > s.size {
+		s.remove(s.recency.Back())
+	}
+
+	return nil
+}
+
+func (s *lruStore) Invalidate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.entries = make(map[string]*list.Element, s.size)
+	s.recency.Init()
+
+	return nil
+}
+
+func (s *lruStore) remove(element *list.Element) {
+	s.recency.Remove(element)
+	delete(s.entries, element.Value.(*lruEntry).key)
+}
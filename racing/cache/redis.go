@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisStore is a Store shared through Redis, so every instance of a service sees the same cached values,
+// and the same invalidations.
+//
+// Values are keyed under a generation, which invalidating moves on, rather than deleting them: values of
+// earlier generations are never read again, and left to expire.
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a store keeping its values in Redis, under keys starting with prefix.
+func NewRedisStore(client *redis.Client, prefix string) Store {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) Generation() (int64, error) {
+	return s.generation(context.Background())
+}
+
+func (s *redisStore) Get(key string) ([]byte, bool, error) {
+	ctx := context.Background()
+
+	generation, err := s.generation(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, err := s.client.Get(ctx, s.key(generation, key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (s *redisStore) Set(generation int64, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(context.Background(), s.key(generation, key), value, ttl).Err()
+}
+
+func (s *redisStore) Invalidate() error {
+	return s.client.Incr(context.Background(), s.prefix+":generation").Err()
+}
+
+// generation returns the current generation of cached values, which is zero until first invalidated.
+func (s *redisStore) generation(ctx context.Context) (int64, error) {
+	generation, err := s.client.Get(ctx, s.prefix+":generation").Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	return generation, err
+}
+
+func (s *redisStore) key(generation int64, key string) string {
+	return s.prefix + ":" + strconv.FormatInt(generation, 10) + ":" + key
+}
@@ -0,0 +1,227 @@
+// Package certs serves TLS certificates from PEM files, and trusts the CAs in them, reloading them as
+// they're renewed, so servers and their clients pick up new certificates without restarting.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Reloader holds a certificate and its private key, and a bundle of CA certificates trusted to sign
+// peers' certificates, each optional, loaded from PEM files. The files are checked for changes at most once an interval, as
+// connections are made, and reloaded when they change. Should they fail to load, e.g. while they're
+// half written, the certificates loaded before are kept until they load again.
+type Reloader struct {
+	certFile, keyFile, caFile string
+	interval                  time.Duration
+
+	mu       sync.Mutex
+	loaded   bool
+	cert     *tls.Certificate
+	ca       *x509.CertPool
+	modTimes []time.Time
+	checked  time.Time
+}
+
+// NewReloader loads a certificate and key, unless certFile is empty, and the CA bundle at caFile, unless
+// it's empty, checking them for changes every interval.
+func NewReloader(certFile, keyFile, caFile string, interval time.Duration) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile, interval: interval}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	r.checked = time.Now()
+
+	return r, nil
+}
+
+// ServerConfig returns the TLS configuration of a server presenting the certificate, which it must have.
+// Given a CA bundle, clients must present a certificate signed by one of its CAs too.
+func (r *Reloader) ServerConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+	}
+
+	if r.caFile != "" {
+		// Client certificates are verified against the CAs current at the time, rather than the ClientCAs
+		// fixed in the configuration.
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			_, ca := r.current()
+			return verifyPeer(state.PeerCertificates, ca, "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+
+	return config
+}
+
+// ClientConfig returns the TLS configuration of a client of the server with the given name, trusting the
+// CA bundle, or the system's CAs without one, and presenting the certificate if there's one.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// The server's certificate is verified against the CAs current at the time by VerifyConnection,
+		// rather than against the RootCAs fixed in the configuration.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			_, ca := r.current()
+			return verifyPeer(state.PeerCertificates, ca, serverName, x509.ExtKeyUsageServerAuth)
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+
+			return cert, nil
+		},
+	}
+}
+
+// DialOption returns the transport credentials to dial the gRPC server at endpoint with: TLS given a CA
+// bundle or a client certificate, presenting the latter if there's one, and plaintext otherwise. The
+// server's certificate must be valid for serverName, by default the endpoint's host. The files are reloaded
+// as NewReloader does.
+func DialOption(caFile, certFile, keyFile, serverName, endpoint string, interval time.Duration) (grpc.DialOption, error) {
+	if caFile == "" && certFile == "" {
+		return grpc.WithInsecure(), nil
+	}
+
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		serverName = host
+	}
+
+	reloader, err := NewReloader(certFile, keyFile, caFile, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(reloader.ClientConfig(serverName))), nil
+}
+
+// current returns the certificate and CAs, reloading them first if they're due a check.
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.Sub(r.checked) >= r.interval {
+		r.checked = now
+
+		if err := r.load(); err != nil {
+			log.Printf("failed reloading certificates, keeping the current ones: %s\n", err)
+		}
+	}
+
+	return r.cert, r.ca
+}
+
+// load loads the files, unless none of them changed since they were last loaded.
+func (r *Reloader) load() error {
+	var files []string
+	if r.certFile != "" {
+		files = append(files, r.certFile, r.keyFile)
+	}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+
+		modTimes[i] = info.ModTime()
+	}
+
+	if r.loaded && sameTimes(modTimes, r.modTimes) {
+		return nil
+	}
+
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		loaded, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.certFile, err)
+		}
+
+		cert = &loaded
+	}
+
+	var ca *x509.CertPool
+	if r.caFile != "" {
+		b, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return err
+		}
+
+		ca = x509.NewCertPool()
+		if !ca.AppendCertsFromPEM(b) {
+			return fmt.Errorf("%s: no certificates found", r.caFile)
+		}
+	}
+
+	r.loaded, r.cert, r.ca, r.modTimes = true, cert, ca, modTimes
+
+	return nil
+}
+
+// verifyPeer verifies a peer's certificate chain, leaf first, was signed by one of the CAs, or the
+// system's CAs if there are none, for the given usage, and the given name unless it's empty.
+func verifyPeer(chain []*x509.Certificate, ca *x509.CertPool, name string, usage x509.ExtKeyUsage) error {
+	if len(chain) == 0 {
+		return errors.New("peer presented no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         ca,
+		DNSName:       name,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+
+	for _, cert := range chain[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := chain[0].Verify(opts)
+
+	return err
+}
+
+func sameTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,359 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestReloader_ServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca, otherCA := newTestCA(t, "ca"), newTestCA(t, "other ca")
+
+	certFile, keyFile := ca.issue(t, dir, "localhost", x509.ExtKeyUsageServerAuth)
+	caFile := writeFile(t, dir, "ca.pem", ca.certPEM)
+
+	reloader, err := NewReloader(certFile, keyFile, caFile, time.Minute)
+	require.NoError(t, err)
+
+	// client trusts the server's CA, presenting a certificate issued by issuer.
+	client := func(issuer *testCA) *tls.Config {
+		cert, err := tls.X509KeyPair(issuer.issuePEM(t, "gateway", x509.ExtKeyUsageClientAuth))
+		require.NoError(t, err)
+
+		config := &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "localhost", Certificates: []tls.Certificate{cert}}
+		config.RootCAs.AppendCertsFromPEM(ca.certPEM)
+
+		return config
+	}
+
+	config := client(ca)
+	state, _, err := handshake(t, reloader.ServerConfig(), config)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", state.PeerCertificates[0].Subject.CommonName)
+
+	// Clients must present a certificate signed by the CA.
+	config.Certificates = nil
+	_, _, err = handshake(t, reloader.ServerConfig(), config)
+	assert.Error(t, err)
+
+	_, _, err = handshake(t, reloader.ServerConfig(), client(otherCA))
+	assert.Error(t, err)
+}
+
+func TestReloader_Reloads(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+
+	certFile, keyFile := ca.issue(t, dir, "racing-1", x509.ExtKeyUsageServerAuth)
+
+	reloader, err := NewReloader(certFile, keyFile, "", 0)
+	require.NoError(t, err)
+
+	client := &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "localhost"}
+	client.RootCAs.AppendCertsFromPEM(ca.certPEM)
+
+	state, _, err := handshake(t, reloader.ServerConfig(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "racing-1", state.PeerCertificates[0].Subject.CommonName)
+
+	// Renewed certificates are served by new connections.
+	certPEM, keyPEM := ca.issuePEM(t, "racing-2", x509.ExtKeyUsageServerAuth)
+	writeFile(t, dir, "racing-1.pem", certPEM)
+	writeFile(t, dir, "racing-1-key.pem", keyPEM)
+	touch(t, time.Minute, certFile, keyFile)
+
+	state, _, err = handshake(t, reloader.ServerConfig(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "racing-2", state.PeerCertificates[0].Subject.CommonName)
+
+	// Until the certificate loads again, the last one is kept.
+	writeFile(t, dir, "racing-1.pem", []byte("half written"))
+	touch(t, 2*time.Minute, certFile)
+
+	state, _, err = handshake(t, reloader.ServerConfig(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "racing-2", state.PeerCertificates[0].Subject.CommonName)
+}
+
+func TestReloader_ClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca, otherCA := newTestCA(t, "ca"), newTestCA(t, "other ca")
+
+	// The racing service requires client certificates signed by the CA.
+	serverCert, err := tls.X509KeyPair(ca.issuePEM(t, "racing", x509.ExtKeyUsageServerAuth))
+	require.NoError(t, err)
+
+	server := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    x509.NewCertPool(),
+	}
+	server.ClientCAs.AddCert(ca.cert)
+
+	certFile, keyFile := ca.issue(t, dir, "gateway", x509.ExtKeyUsageClientAuth)
+	caFile := writeFile(t, dir, "ca.pem", ca.certPEM)
+
+	reloader, err := NewReloader(certFile, keyFile, caFile, time.Minute)
+	require.NoError(t, err)
+
+	client, serverSide, err := handshake(t, server, reloader.ClientConfig("localhost"))
+	require.NoError(t, err)
+	assert.Equal(t, "racing", client.PeerCertificates[0].Subject.CommonName)
+	assert.Equal(t, "gateway", serverSide.PeerCertificates[0].Subject.CommonName)
+
+	// The server's certificate must be valid for its name...
+	_, _, err = handshake(t, server, reloader.ClientConfig("racing.example.com"))
+	assert.Error(t, err)
+
+	// ... and signed by a trusted CA.
+	untrusting, err := NewReloader(certFile, keyFile, writeFile(t, dir, "other-ca.pem", otherCA.certPEM), time.Minute)
+	require.NoError(t, err)
+
+	_, _, err = handshake(t, server, untrusting.ClientConfig("localhost"))
+	assert.Error(t, err)
+
+	// Without a client certificate, the server refuses the connection.
+	anonymous, err := NewReloader("", "", caFile, time.Minute)
+	require.NoError(t, err)
+
+	_, _, err = handshake(t, server, anonymous.ClientConfig("localhost"))
+	assert.Error(t, err)
+}
+
+func TestReloader_ReloadsCAs(t *testing.T) {
+	dir := t.TempDir()
+	ca, renewedCA := newTestCA(t, "ca"), newTestCA(t, "renewed ca")
+
+	certFile, keyFile := ca.issue(t, dir, "api", x509.ExtKeyUsageServerAuth)
+	caFile := writeFile(t, dir, "ca.pem", ca.certPEM)
+
+	server, err := NewReloader(certFile, keyFile, "", 0)
+	require.NoError(t, err)
+
+	client, err := NewReloader("", "", caFile, 0)
+	require.NoError(t, err)
+
+	state, _, err := handshake(t, server.ServerConfig(), client.ClientConfig("localhost"))
+	require.NoError(t, err)
+	assert.Equal(t, "ca", state.PeerCertificates[0].Issuer.CommonName)
+
+	// Renewed certificates, and the CAs trusted to sign them, are used by new connections.
+	certPEM, keyPEM := renewedCA.issuePEM(t, "api", x509.ExtKeyUsageServerAuth)
+	writeFile(t, dir, "api.pem", certPEM)
+	writeFile(t, dir, "api-key.pem", keyPEM)
+	writeFile(t, dir, "ca.pem", renewedCA.certPEM)
+	touch(t, time.Minute, certFile, keyFile, caFile)
+
+	state, _, err = handshake(t, server.ServerConfig(), client.ClientConfig("localhost"))
+	require.NoError(t, err)
+	assert.Equal(t, "renewed ca", state.PeerCertificates[0].Issuer.CommonName)
+
+	// Until the files load again, the last certificates are kept.
+	writeFile(t, dir, "api.pem", []byte("half written"))
+	writeFile(t, dir, "ca.pem", nil)
+	touch(t, 2*time.Minute, certFile, caFile)
+
+	state, _, err = handshake(t, server.ServerConfig(), client.ClientConfig("localhost"))
+	require.NoError(t, err)
+	assert.Equal(t, "renewed ca", state.PeerCertificates[0].Issuer.CommonName)
+}
+
+func TestDialOption(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+
+	certFile, keyFile := ca.issue(t, dir, "racing", x509.ExtKeyUsageServerAuth)
+	caFile := writeFile(t, dir, "ca.pem", ca.certPEM)
+
+	reloader, err := NewReloader(certFile, keyFile, "", time.Minute)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+
+	check := func(serverName string) error {
+		endpoint := net.JoinHostPort("localhost", port)
+
+		opt, err := DialOption(caFile, "", "", serverName, endpoint, time.Minute)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, endpoint, opt)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+
+		return err
+	}
+
+	// The server's name defaults to the endpoint's host...
+	assert.NoError(t, check(""))
+
+	// ... and its certificate must be valid for the name given instead.
+	assert.Error(t, check("racing.example.com"))
+
+	_, err = DialOption(caFile, "", "", "", "localhost", time.Minute)
+	assert.Error(t, err)
+
+	_, err = DialOption(filepath.Join(dir, "missing.pem"), "", "", "", "localhost:9000", time.Minute)
+	assert.Error(t, err)
+}
+
+func TestNewReloader_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+
+	certFile, keyFile := ca.issue(t, dir, "localhost", x509.ExtKeyUsageServerAuth)
+
+	_, err := NewReloader(certFile, filepath.Join(dir, "missing.pem"), "", time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewReloader(certFile, keyFile, writeFile(t, dir, "empty.pem", nil), time.Minute)
+	assert.Error(t, err)
+}
+
+// testCA is a certificate authority issuing certificates for tests.
+type testCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issuePEM issues a certificate for localhost, returning it and its key PEM encoded.
+func (ca *testCA) issuePEM(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// issue issues a certificate, writing it and its key to the files named after the subject's name in dir.
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	certPEM, keyPEM := ca.issuePEM(t, name, usage)
+
+	return writeFile(t, dir, name+".pem", certPEM), writeFile(t, dir, name+"-key.pem", keyPEM)
+}
+
+func writeFile(t *testing.T, dir, name string, b []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, b, 0600))
+
+	return path
+}
+
+// touch moves the modification times of the files ahead, as file systems may not tell apart writes
+// made in quick succession.
+func touch(t *testing.T, ahead time.Duration, files ...string) {
+	for _, file := range files {
+		require.NoError(t, os.Chtimes(file, time.Now().Add(ahead), time.Now().Add(ahead)))
+	}
+}
+
+// handshake makes a TLS connection between a server and a client, returning both sides' view of it.
+func handshake(t *testing.T, server, client *tls.Config) (clientSide, serverSide tls.ConnectionState, err error) {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	type accepted struct {
+		state tls.ConnectionState
+		err   error
+	}
+
+	accepts := make(chan accepted, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			accepts <- accepted{err: err}
+			return
+		}
+		defer conn.Close()
+
+		err = conn.(*tls.Conn).Handshake()
+		accepts <- accepted{state: conn.(*tls.Conn).ConnectionState(), err: err}
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), client)
+	if err != nil {
+		return clientSide, serverSide, err
+	}
+	defer conn.Close()
+
+	// Clients can finish their side of a TLS 1.3 handshake before the server verifies their certificate.
+	accept := <-accepts
+
+	return conn.ConnectionState(), accept.state, accept.err
+}
@@ -0,0 +1,192 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/cache"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"google.golang.org/protobuf/proto"
+)
+
+// RaceCache caches the pages of races listed by a races repository, until they expire, or until a race
+// is written through the repositories it wraps.
+//
+// Race statuses are derived when races are listed, so a cached page's statuses, and which races match a
+// status filter, can lag by up to the TTL. Writes made by other processes are likewise only seen once the
+// cached pages expire, unless the processes share the store, e.g. through Redis.
+type RaceCache struct {
+	store   cache.Store
+	ttl     time.Duration
+	metrics *expvar.Map
+}
+
+// NewRaceCache creates a cache keeping pages of races in store for ttl. The cache's hits, misses,
+// invalidations and errors are counted in metrics.
+func NewRaceCache(store cache.Store, ttl time.Duration, metrics *expvar.Map) *RaceCache {
+	for _, name := range []string{"hits", "misses", "invalidations", "errors"} {
+		metrics.Add(name, 0)
+	}
+
+	return &RaceCache{store: store, ttl: ttl, metrics: metrics}
+}
+
+// Races wraps a races repository, reading the pages of races it lists through the cache, and dropping
+// the cached pages whenever a race is created, updated, deleted or imported.
+func (c *RaceCache) Races(repo RacesRepo) RacesRepo {
+	return &cachedRacesRepo{RacesRepo: repo, cache: c}
+}
+
+// Results wraps a results repository, dropping the cached pages of races whenever a result is saved, as
+// results change the status of their race.
+func (c *RaceCache) Results(repo ResultsRepo) ResultsRepo {
+	return &cachedResultsRepo{ResultsRepo: repo, cache: c}
+}
+
+// invalidate drops every cached page. A failure leaves the pages to expire, as the write went through.
+func (c *RaceCache) invalidate() {
+	c.metrics.Add("invalidations", 1)
+
+	if err := c.store.Invalidate(); err != nil {
+		c.metrics.Add("errors", 1)
+	}
+}
+
+// cachedRacesRepo is a RacesRepo listing races through a RaceCache.
+type cachedRacesRepo struct {
+	RacesRepo
+	cache *RaceCache
+}
+
+func (r *cachedRacesRepo) List(in *racing.ListRacesRequest) ([]*racing.Race, string, error) {
+	key, err := listCacheKey(in)
+	if err != nil {
+		// The request is invalid, which the repository reports.
+		return r.RacesRepo.List(in)
+	}
+
+	c := r.cache
+
+	cached, ok, err := c.store.Get(key)
+	if err != nil {
+		c.metrics.Add("errors", 1)
+	}
+
+	if ok {
+		page := &racing.ListRacesResponse{}
+		if err := proto.Unmarshal(cached, page); err == nil {
+			c.metrics.Add("hits", 1)
+			return page.Races, page.NextPageToken, nil
+		}
+
+		c.metrics.Add("errors", 1)
+	}
+
+	c.metrics.Add("misses", 1)
+
+	// A race written while the page is listed may or may not be reflected in it, so it's cached as of the
+	// generation before, which the write moves the store on from.
+	generation, err := c.store.Generation()
+	if err != nil {
+		c.metrics.Add("errors", 1)
+		return r.RacesRepo.List(in)
+	}
+
+	races, nextPageToken, err := r.RacesRepo.List(in)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := proto.Marshal(&racing.ListRacesResponse{Races: races, NextPageToken: nextPageToken})
+	if err == nil {
+		err = c.store.Set(generation, key, page, c.ttl)
+	}
+
+	if err != nil {
+		c.metrics.Add("errors", 1)
+	}
+
+	return races, nextPageToken, nil
+}
+
+func (r *cachedRacesRepo) Create(race *racing.Race) (*racing.Race, error) {
+	created, err := r.RacesRepo.Create(race)
+	if err == nil {
+		r.cache.invalidate()
+	}
+
+	return created, err
+}
+
+func (r *cachedRacesRepo) Update(race *racing.Race, fields []string) (*racing.Race, error) {
+	updated, err := r.RacesRepo.Update(race, fields)
+	if err == nil {
+		r.cache.invalidate()
+	}
+
+	return updated, err
+}
+
+func (r *cachedRacesRepo) Delete(id int64, version int64) error {
+	err := r.RacesRepo.Delete(id, version)
+	if err == nil {
+		r.cache.invalidate()
+	}
+
+	return err
+}
+
+func (r *cachedRacesRepo) Import(races []*racing.Race) ([]ImportOutcome, error) {
+	outcomes, err := r.RacesRepo.Import(races)
+	if err == nil {
+		r.cache.invalidate()
+	}
+
+	return outcomes, err
+}
+
+// cachedResultsRepo is a ResultsRepo invalidating a RaceCache as results are saved.
+type cachedResultsRepo struct {
+	ResultsRepo
+	cache *RaceCache
+}
+
+func (r *cachedResultsRepo) Save(result *racing.RaceResult) (*racing.RaceResult, error) {
+	saved, err := r.ResultsRepo.Save(result)
+	if err == nil {
+		r.cache.invalidate()
+	}
+
+	return saved, err
+}
+
+// listCacheKey identifies the page of races a request lists, so requests for the same page share a key
+// however they spell it. It returns an error for requests the repository would reject.
+func listCacheKey(in *racing.ListRacesRequest) (string, error) {
+	order, err := parseOrderBy(in.GetOrderBy())
+	if err != nil {
+		return "", err
+	}
+
+	size, err := pageSize(in.GetPageSize())
+	if err != nil {
+		return "", err
+	}
+
+	// Next to jump always lists a single page in the default order.
+	if in.GetFilter().GetNextToJump() > 0 {
+		order, size = nil, 0
+	}
+
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(normaliseFilter(in.GetFilter()))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%x|%s|%d|%s", b, orderByString(order), size, in.GetPageToken())))
+
+	return "races:list:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"database/sql"
+	"expvar"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/cache"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRaceCache(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		metrics := new(expvar.Map).Init()
+		raceCache := NewRaceCache(cache.NewLRUStore(100), time.Minute, metrics)
+		racesRepo := raceCache.Races(NewRacesRepo(racingDB))
+
+		list := func(meetingIDs ...int64) []*racing.Race {
+			races, _, err := racesRepo.List(&racing.ListRacesRequest{
+				Filter:  &racing.ListRacesRequestFilter{MeetingIds: meetingIDs},
+				OrderBy: "number",
+			})
+			require.NoError(t, err)
+
+			return races
+		}
+
+		first := list(1, 2)
+		assert.Equal(t, "0", metrics.Get("hits").String())
+		assert.Equal(t, "1", metrics.Get("misses").String())
+
+		// The same filter, spelt differently, is served from the cache.
+		assert.Equal(t, raceIDs(first), raceIDs(list(2, 1, 2)))
+		assert.Equal(t, "1", metrics.Get("hits").String())
+		assert.Equal(t, "1", metrics.Get("misses").String())
+
+		// Writing a race drops the cached pages, so the next list sees the write.
+		race := first[0]
+		race.Name = "Cached Stakes"
+		_, err := racesRepo.Update(race, []string{"name"})
+		require.NoError(t, err)
+		assert.Equal(t, "1", metrics.Get("invalidations").String())
+
+		listed := list(1, 2)
+		assert.Equal(t, "2", metrics.Get("misses").String())
+		if assert.NotEmpty(t, listed) {
+			assert.Equal(t, "Cached Stakes", listed[0].Name)
+		}
+
+		created, err := racesRepo.Create(&racing.Race{
+			MeetingId:           1,
+			Name:                "Late Addition",
+			Number:              99,
+			AdvertisedStartTime: timestamppb.New(time.Now().Add(time.Hour)),
+		})
+		require.NoError(t, err)
+		assert.Contains(t, raceIDs(list(1, 2)), created.Id)
+
+		require.NoError(t, racesRepo.Delete(created.Id, created.Version))
+		assert.NotContains(t, raceIDs(list(1, 2)), created.Id)
+
+		// Saving a result changes its race's status, so it drops the cached pages too.
+		resulted := racing.Race_RESULTED
+		listResulted := func() []*racing.Race {
+			races, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: &racing.ListRacesRequestFilter{Status: &resulted}})
+			require.NoError(t, err)
+
+			return races
+		}
+
+		assert.Empty(t, listResulted())
+
+		_, err = raceCache.Results(NewResultsRepo(racingDB)).Save(&racing.RaceResult{
+			RaceId:   1,
+			Status:   racing.RaceResult_OFFICIAL,
+			Placings: []*racing.Placing{{RunnerId: 1, Position: 1}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1}, raceIDs(listResulted()))
+
+		// Failed writes leave the cache alone.
+		invalidations := metrics.Get("invalidations").String()
+		_, err = racesRepo.Update(&racing.Race{Id: 1, Name: "Stale", Version: 1000}, []string{"name"})
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		assert.Equal(t, invalidations, metrics.Get("invalidations").String())
+
+		// Invalid requests aren't cached, and are rejected by the repository.
+		_, _, err = racesRepo.List(&racing.ListRacesRequest{OrderBy: "unknown"})
+		assert.ErrorIs(t, err, ErrInvalidOrderBy)
+	})
+}
+
+func TestRaceCache_WriteWhileCaching(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		metrics := new(expvar.Map).Init()
+		store := &writingStore{Store: cache.NewLRUStore(100)}
+		raceCache := NewRaceCache(store, time.Minute, metrics)
+		racesRepo := raceCache.Races(NewRacesRepo(racingDB))
+
+		// The race is renamed after the page was listed, but before it's cached.
+		store.write = func() {
+			race, err := racesRepo.Get(1)
+			require.NoError(t, err)
+
+			race.Name = "Renamed Stakes"
+			_, err = racesRepo.Update(race, []string{"name"})
+			require.NoError(t, err)
+		}
+
+		list := func() []*racing.Race {
+			races, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: &racing.ListRacesRequestFilter{MeetingIds: []int64{1}}})
+			require.NoError(t, err)
+
+			return races
+		}
+
+		assert.NotContains(t, raceNames(list()), "Renamed Stakes")
+
+		// The stale page wasn't cached, so the rename is seen straight away.
+		assert.Contains(t, raceNames(list()), "Renamed Stakes")
+		assert.Equal(t, "0", metrics.Get("hits").String())
+	})
+}
+
+// writingStore is a cache.Store making a write once, just before caching a value.
+type writingStore struct {
+	cache.Store
+	write func()
+}
+
+func (s *writingStore) Set(generation int64, key string, value []byte, ttl time.Duration) error {
+	if write := s.write; write != nil {
+		s.write = nil
+		write()
+	}
+
+	return s.Store.Set(generation, key, value, ttl)
+}
+
+func raceNames(races []*racing.Race) []string {
+	var names []string
+	for _, race := range races {
+		names = append(names, race.Name)
+	}
+
+	return names
+}
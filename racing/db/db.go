@@ -1,30 +1,171 @@
 package db
 
 import (
+	"database/sql"
+	"math"
 	"time"
 
+	"git.neds.sh/matty/entain/racing/proto/racing"
 	"syreclabs.com/go/faker"
 )
 
-func (r *racesRepo) seed() error {
-	statement, err := r.db.Prepare(`CREATE TABLE IF NOT EXISTS races (id INTEGER PRIMARY KEY, meeting_id INTEGER, name TEXT, number INTEGER, visible INTEGER, advertised_start_time DATETIME)`)
-	if err == nil {
-		_, err = statement.Exec()
+// maxSeedRunners is the largest field seeded races are given.
+const maxSeedRunners = 14
+
+// seedCountries is the set of countries seeded meetings are drawn from.
+var seedCountries = []string{"AUS", "NZL", "GBR", "IRL", "USA"}
+
+// SeedOptions control the dummy data written by Seed.
+type SeedOptions struct {
+	// Seed is the seed of the random data. The same seed always produces the same data.
+	Seed int64
+	// Now is the time the seeded races are scheduled around, from a day before it to two days after.
+	Now time.Time
+}
+
+// Seed fills the database with dummy meetings, races, runners and prices, for test/example purposes. Rows that
+// already exist are left alone, so seeding an already seeded database is a no-op.
+func Seed(db *sql.DB, opts SeedOptions) error {
+	// faker draws from a single, shared source of randomness.
+	faker.Seed(opts.Seed)
+
+	d := dialectOf(db)
+
+	for _, seed := range []func(*sql.DB, dialect, time.Time) error{seedMeetings, seedRaces, seedRunners, seedPrices} {
+		if err := seed(db, d, opts.Now); err != nil {
+			return err
+		}
+	}
+
+	// Races created through CreateRace, and prices recorded through UpdatePrices, are numbered after
+	// the seeded ones.
+	for _, table := range []string{"races", "prices"} {
+		if err := d.syncSequence(db, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func seedMeetings(db *sql.DB, dialect dialect, now time.Time) error {
+	statement, err := db.Prepare(dialect.rebind(`INSERT INTO meetings(id, venue, country, race_type, date) VALUES (?,?,?,?,?) ON CONFLICT DO NOTHING`))
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Seeded races belong to meetings 1 to 10.
+	for i := 1; i <= 10; i++ {
+		if _, err := statement.Exec(
+			i,
+			faker.Address().City(),
+			faker.RandomChoice(seedCountries),
+			faker.Number().Between(int(racing.Meeting_THOROUGHBRED), int(racing.Meeting_GREYHOUND)),
+			now.AddDate(0, 0, faker.RandomInt(-1, 1)).Format(meetingDateLayout),
+		); err != nil {
+			return err
+		}
 	}
 
+	return nil
+}
+
+func seedRaces(db *sql.DB, dialect dialect, now time.Time) error {
+	statement, err := db.Prepare(dialect.rebind(`INSERT INTO races(id, meeting_id, name, number, visible, advertised_start_time) VALUES (?,?,?,?,?,?) ON CONFLICT DO NOTHING`))
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
 	for i := 1; i <= 100; i++ {
-		statement, err = r.db.Prepare(`INSERT OR IGNORE INTO races(id, meeting_id, name, number, visible, advertised_start_time) VALUES (?,?,?,?,?,?)`)
-		if err == nil {
-			_, err = statement.Exec(
-				i,
-				faker.Number().Between(1, 10),
-				faker.Team().Name(),
-				faker.Number().Between(1, 12),
-				faker.Number().Between(0, 1),
-				faker.Time().Between(time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 2)).Format(time.RFC3339),
-			)
+		if _, err := statement.Exec(
+			i,
+			faker.Number().Between(1, 10),
+			faker.Team().Name(),
+			faker.Number().Between(1, 12),
+			faker.Number().Between(0, 1),
+			timeArg(faker.Time().Between(now.AddDate(0, 0, -1), now.AddDate(0, 0, 2))),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func seedRunners(db *sql.DB, dialect dialect, _ time.Time) error {
+	statement, err := db.Prepare(dialect.rebind(`INSERT INTO runners(id, race_id, number, name, barrier, jockey, trainer, weight, scratched) VALUES (?,?,?,?,?,?,?,?,?) ON CONFLICT DO NOTHING`))
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Each of the seeded races gets a field of up to maxSeedRunners runners.
+	for race := 1; race <= 100; race++ {
+		fieldSize := faker.RandomInt(6, maxSeedRunners)
+
+		for number := 1; number <= fieldSize; number++ {
+			if _, err := statement.Exec(
+				(race-1)*maxSeedRunners+number,
+				race,
+				number,
+				faker.Name().FirstName()+" "+faker.Hacker().Noun(),
+				faker.RandomInt(1, maxSeedRunners),
+				faker.Name().Name(),
+				faker.Name().Name(),
+				float64(faker.RandomInt(500, 620))/10,
+				faker.RandomInt(0, 9) == 0,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func seedPrices(db *sql.DB, dialect dialect, now time.Time) error {
+	rows, err := db.Query(dialect.rebind(`SELECT id, race_id FROM runners WHERE scratched = ? ORDER BY id ASC`), false)
+	if err != nil {
+		return err
+	}
+
+	type runner struct{ id, raceID int64 }
+
+	var runners []runner
+	for rows.Next() {
+		var r runner
+		if err := rows.Scan(&r.id, &r.raceID); err != nil {
+			rows.Close()
+			return err
+		}
+
+		runners = append(runners, r)
+	}
+	rows.Close()
+
+	statement, err := db.Prepare(dialect.rebind(`INSERT INTO prices(id, race_id, runner_id, win, place, updated_time) VALUES (?,?,?,?,?,?) ON CONFLICT DO NOTHING`))
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Each unscratched runner gets an opening price, numbered after the runner so reseeding is a no-op.
+	for _, r := range runners {
+		win := float64(faker.RandomInt(150, 5100)) / 100
+
+		if _, err := statement.Exec(
+			r.id,
+			r.raceID,
+			r.id,
+			win,
+			math.Round((1+(win-1)/4)*100)/100,
+			timeArg(now),
+		); err != nil {
+			return err
 		}
 	}
 
-	return err
+	return nil
 }
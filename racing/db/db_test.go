@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// postgresDSNEnv names the environment variable holding the URL of a PostgreSQL database to also run
+// the repository tests against, e.g. "postgres://postgres@localhost/racing?sslmode=disable". The
+// PostgreSQL tests are skipped when it isn't set.
+const postgresDSNEnv = "RACING_TEST_POSTGRES_DSN"
+
+// forEachBackend runs a test against a freshly migrated and seeded database of each supported backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, racingDB *sql.DB)) {
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, createDB(t))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		test(t, createPostgresDB(t))
+	})
+}
+
+// openDB opens an empty in-memory database.
+func openDB(t *testing.T) *sql.DB {
+	racingDB, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { racingDB.Close() })
+
+	// Keep the in-memory database alive across the pool's connections.
+	racingDB.SetMaxOpenConns(1)
+
+	return racingDB
+}
+
+// createDB opens an in-memory database, migrated to the latest schema and seeded.
+func createDB(t *testing.T) *sql.DB {
+	racingDB := openDB(t)
+	migrateDB(t, racingDB)
+	seedDB(t, racingDB)
+
+	return racingDB
+}
+
+// createPostgresDB opens a connection to the PostgreSQL test database, scoped to a schema of its own
+// that is dropped once the test finishes, migrated to the latest schema and seeded.
+func createPostgresDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	adminDB, err := Open(dsn)
+	require.NoError(t, err)
+
+	schema := fmt.Sprintf("racing_test_%d", time.Now().UnixNano())
+	_, err = adminDB.Exec(`CREATE SCHEMA ` + schema)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		adminDB.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		adminDB.Close()
+	})
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	racingDB, err := Open(u.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { racingDB.Close() })
+
+	migrateDB(t, racingDB)
+	seedDB(t, racingDB)
+
+	return racingDB
+}
+
+func migrateDB(t *testing.T, racingDB *sql.DB) {
+	migrator, err := NewMigrator(racingDB)
+	require.NoError(t, err)
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+}
+
+func seedDB(t *testing.T, racingDB *sql.DB) {
+	require.NoError(t, Seed(racingDB, SeedOptions{Seed: 1, Now: time.Now()}))
+}
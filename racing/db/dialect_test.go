@@ -0,0 +1,24 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialect_Rebind(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		dialect dialect
+		query   string
+		want    string
+	}{
+		{"sqlite", sqliteDialect, `SELECT id FROM races WHERE id = ? AND version = ?`, `SELECT id FROM races WHERE id = ? AND version = ?`},
+		{"postgres", postgresDialect, `SELECT id FROM races WHERE id = ? AND version = ?`, `SELECT id FROM races WHERE id = $1 AND version = $2`},
+		{"postgres quoted", postgresDialect, `SELECT id FROM races WHERE name = 'who?' AND id IN (?,?)`, `SELECT id FROM races WHERE name = 'who?' AND id IN ($1,$2)`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.dialect.rebind(tc.query))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// FeedRepo provides repository access to the progress of feed ingestion.
+type FeedRepo interface {
+	// Sequence will return the sequence of the latest feed message applied to a race, or zero if none
+	// has been.
+	Sequence(sourceID string) (int64, error)
+
+	// SetSequence will record the sequence of the latest feed message applied to a race. Sequences
+	// only ever move forwards, so recording an older sequence is a no-op.
+	SetSequence(sourceID string, sequence int64) error
+}
+
+type feedRepo struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewFeedRepo creates a new feed repository.
+func NewFeedRepo(db *sql.DB) FeedRepo {
+	return &feedRepo{db: db, dialect: dialectOf(db)}
+}
+
+func (r *feedRepo) Sequence(sourceID string) (int64, error) {
+	var sequence int64
+
+	err := r.db.QueryRow(r.dialect.rebind(`SELECT sequence FROM feed_sequences WHERE source_id = ?`), sourceID).Scan(&sequence)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return sequence, err
+}
+
+func (r *feedRepo) SetSequence(sourceID string, sequence int64) error {
+	_, err := r.db.Exec(
+		r.dialect.rebind(`INSERT INTO feed_sequences(source_id, sequence) VALUES (?,?)
+			ON CONFLICT (source_id) DO UPDATE SET sequence = excluded.sequence WHERE excluded.sequence > feed_sequences.sequence`),
+		sourceID,
+		sequence,
+	)
+
+	return err
+}
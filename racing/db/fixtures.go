@@ -0,0 +1,299 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrInvalidFixture is returned when a fixture file can't be read into meetings or races.
+var ErrInvalidFixture = errors.New("invalid fixture")
+
+// Fixtures are known meetings and races, loaded into the database for integration tests and demos.
+type Fixtures struct {
+	Meetings []*racing.Meeting
+	Races    []*racing.Race
+}
+
+// ReadFixtures reads fixtures from a file.
+//
+// JSON files hold an object with "meetings" and "races" arrays, whose elements are in the proto JSON
+// format of Meeting and Race. CSV files hold a single kind of record, named by the start of the file
+// name (e.g. "races.csv" or "meetings_demo.csv"), and have a header row naming the field of each column.
+// Every record must have an ID, and every race an advertised start time.
+func ReadFixtures(path string) (*Fixtures, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var fixtures *Fixtures
+
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".json"):
+		fixtures, err = readJSONFixtures(file)
+	case strings.HasSuffix(name, ".csv") && strings.HasPrefix(name, "meetings"):
+		fixtures, err = readCSVFixtures(file, addMeeting)
+	case strings.HasSuffix(name, ".csv") && strings.HasPrefix(name, "races"):
+		fixtures, err = readCSVFixtures(file, addRace)
+	default:
+		err = fmt.Errorf("%w: neither a JSON file nor a meetings or races CSV file", ErrInvalidFixture)
+	}
+
+	if err == nil {
+		err = fixtures.validate()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return fixtures, nil
+}
+
+// ReadRaces reads the races of an import file: either a CSV file of races, or a JSON file with a
+// "races" array, in the same formats as fixtures.
+func ReadRaces(path string) ([]*racing.Race, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var fixtures *Fixtures
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		fixtures, err = readJSONFixtures(file)
+	case ".csv":
+		fixtures, err = readCSVFixtures(file, addRace)
+	default:
+		err = fmt.Errorf("%w: neither a JSON nor a CSV file", ErrInvalidFixture)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return fixtures.Races, nil
+}
+
+func readJSONFixtures(r io.Reader) (*Fixtures, error) {
+	var file struct {
+		Meetings []json.RawMessage `json:"meetings"`
+		Races    []json.RawMessage `json:"races"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFixture, err)
+	}
+
+	fixtures := &Fixtures{}
+
+	for i, raw := range file.Meetings {
+		meeting := &racing.Meeting{}
+		if err := protojson.Unmarshal(raw, meeting); err != nil {
+			return nil, fmt.Errorf("%w: meeting %d: %s", ErrInvalidFixture, i+1, err)
+		}
+
+		fixtures.Meetings = append(fixtures.Meetings, meeting)
+	}
+
+	for i, raw := range file.Races {
+		race := &racing.Race{}
+		if err := protojson.Unmarshal(raw, race); err != nil {
+			return nil, fmt.Errorf("%w: race %d: %s", ErrInvalidFixture, i+1, err)
+		}
+
+		fixtures.Races = append(fixtures.Races, race)
+	}
+
+	return fixtures, nil
+}
+
+// readCSVFixtures reads each row of a CSV file into the message add appends to the fixtures.
+func readCSVFixtures(r io.Reader, add func(*Fixtures) protoreflect.Message) (*Fixtures, error) {
+	message := add(&Fixtures{}).Descriptor()
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading header: %s", ErrInvalidFixture, err)
+	}
+
+	columns := make([]protoreflect.FieldDescriptor, len(header))
+	for i, name := range header {
+		if columns[i] = message.Fields().ByName(protoreflect.Name(strings.TrimSpace(name))); columns[i] == nil {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidFixture, name)
+		}
+	}
+
+	fixtures := &Fixtures{}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return fixtures, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFixture, err)
+		}
+
+		fixture := add(fixtures)
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+
+			if err := setCSVField(fixture, columns[i], value); err != nil {
+				return nil, fmt.Errorf("%w: row %d: %s: %s", ErrInvalidFixture, row, columns[i].Name(), err)
+			}
+		}
+	}
+}
+
+func addMeeting(f *Fixtures) protoreflect.Message {
+	meeting := &racing.Meeting{}
+	f.Meetings = append(f.Meetings, meeting)
+
+	return meeting.ProtoReflect()
+}
+
+func addRace(f *Fixtures) protoreflect.Message {
+	race := &racing.Race{}
+	f.Races = append(f.Races, race)
+
+	return race.ProtoReflect()
+}
+
+// setCSVField parses a CSV value into a field of a message.
+func setCSVField(message protoreflect.Message, field protoreflect.FieldDescriptor, value string) error {
+	switch {
+	case field.IsList():
+		return errors.New("repeated fields can't be read from CSV")
+	case field.Kind() == protoreflect.StringKind:
+		message.Set(field, protoreflect.ValueOfString(value))
+	case field.Kind() == protoreflect.Int64Kind:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		message.Set(field, protoreflect.ValueOfInt64(v))
+	case field.Kind() == protoreflect.BoolKind:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		message.Set(field, protoreflect.ValueOfBool(v))
+	case field.Kind() == protoreflect.EnumKind:
+		enum := field.Enum().Values().ByName(protoreflect.Name(value))
+		if enum == nil {
+			return fmt.Errorf("unknown value %q", value)
+		}
+
+		message.Set(field, protoreflect.ValueOfEnum(enum.Number()))
+	case field.Message() != nil && field.Message().FullName() == "google.protobuf.Timestamp":
+		v, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+
+		message.Set(field, protoreflect.ValueOfMessage(timestamppb.New(v).ProtoReflect()))
+	default:
+		return errors.New("field can't be read from CSV")
+	}
+
+	return nil
+}
+
+func (f *Fixtures) validate() error {
+	for i, meeting := range f.Meetings {
+		if meeting.Id <= 0 {
+			return fmt.Errorf("%w: meeting %d has no id", ErrInvalidFixture, i+1)
+		}
+	}
+
+	for i, race := range f.Races {
+		if race.Id <= 0 {
+			return fmt.Errorf("%w: race %d has no id", ErrInvalidFixture, i+1)
+		}
+
+		if race.AdvertisedStartTime == nil {
+			return fmt.Errorf("%w: race %d has no advertised_start_time", ErrInvalidFixture, race.Id)
+		}
+	}
+
+	return nil
+}
+
+// LoadFixtures writes fixtures to the database in a single transaction. Meetings and races replace any
+// stored with the same ID, so loading the same fixtures again is a no-op.
+func LoadFixtures(db *sql.DB, fixtures *Fixtures) error {
+	d := dialectOf(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, meeting := range fixtures.Meetings {
+		if _, err := tx.Exec(
+			d.rebind(`INSERT INTO meetings(id, venue, country, race_type, date) VALUES (?,?,?,?,?)
+				ON CONFLICT (id) DO UPDATE SET venue = excluded.venue, country = excluded.country, race_type = excluded.race_type, date = excluded.date`),
+			meeting.Id,
+			meeting.Venue,
+			meeting.Country,
+			int32(meeting.RaceType),
+			meeting.Date,
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, race := range fixtures.Races {
+		// A race only moves on to a new version when the fixture actually changes it.
+		if _, err := tx.Exec(
+			d.rebind(`INSERT INTO races(id, meeting_id, name, number, visible, advertised_start_time) VALUES (?,?,?,?,?,?)
+				ON CONFLICT (id) DO UPDATE SET meeting_id = excluded.meeting_id, name = excluded.name, number = excluded.number,
+					visible = excluded.visible, advertised_start_time = excluded.advertised_start_time, version = races.version + 1
+				WHERE races.meeting_id != excluded.meeting_id OR races.name != excluded.name OR races.number != excluded.number
+					OR races.visible != excluded.visible OR races.advertised_start_time != excluded.advertised_start_time`),
+			race.Id,
+			race.MeetingId,
+			race.Name,
+			race.Number,
+			race.Visible,
+			timeArg(race.AdvertisedStartTime.AsTime()),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Races created through CreateRace are numbered after the loaded ones.
+	return d.syncSequence(db, "races")
+}
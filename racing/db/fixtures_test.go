@@ -0,0 +1,125 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReadFixtures(t *testing.T) {
+	fixtures, err := ReadFixtures("testdata/fixtures.json")
+	require.NoError(t, err)
+	assert.Len(t, fixtures.Meetings, 1)
+	assert.Len(t, fixtures.Races, 2)
+	assert.Equal(t, racing.Meeting_THOROUGHBRED, fixtures.Meetings[0].RaceType)
+	assert.Equal(t, "Australian Cup", fixtures.Races[1].Name)
+
+	fixtures, err = ReadFixtures("testdata/meetings.csv")
+	require.NoError(t, err)
+	assert.Empty(t, fixtures.Races)
+	assert.True(t, proto.Equal(&racing.Meeting{Id: 3, Venue: "Wentworth Park", Country: "AUS", RaceType: racing.Meeting_GREYHOUND, Date: "2021-03-06"}, fixtures.Meetings[1]))
+
+	fixtures, err = ReadFixtures("testdata/races.csv")
+	require.NoError(t, err)
+	assert.Empty(t, fixtures.Meetings)
+	assert.Len(t, fixtures.Races, 2)
+	assert.True(t, fixtures.Races[0].Visible)
+	assert.Equal(t, time.Date(2021, 3, 6, 11, 52, 0, 0, time.UTC), fixtures.Races[1].AdvertisedStartTime.AsTime())
+}
+
+func TestReadFixtures_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"unknown.csv":       "id,name\n1,Race",
+		"races_column.csv":  "id,distance\n1,1200",
+		"races_value.csv":   "id,number\n1,first",
+		"races_no_id.csv":   "name,advertised_start_time\nRace,2021-03-06T06:25:00Z",
+		"races_no_time.csv": "id,name\n1,Race",
+		"meetings_enum.csv": "id,race_type\n1,CAMEL",
+		"fixtures.json":     `{"races": [{"id": "one"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		_, err := ReadFixtures(path)
+		assert.ErrorIsf(t, err, ErrInvalidFixture, "Reading %s", name)
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := NewRacesRepo(racingDB)
+		meetingsRepo := NewMeetingsRepo(racingDB)
+
+		for _, path := range []string{"testdata/fixtures.json", "testdata/meetings.csv", "testdata/races.csv"} {
+			fixtures, err := ReadFixtures(path)
+			require.NoError(t, err)
+			require.NoError(t, LoadFixtures(racingDB, fixtures))
+		}
+
+		meeting, err := meetingsRepo.Get(3)
+		require.NoError(t, err)
+		assert.Equal(t, "Wentworth Park", meeting.Venue)
+
+		race, err := racesRepo.Get(1)
+		require.NoError(t, err)
+		assert.Equal(t, "Newmarket Handicap", race.Name)
+		assert.Equal(t, time.Date(2021, 3, 6, 5, 45, 0, 0, time.UTC), race.AdvertisedStartTime.AsTime().UTC())
+
+		// Loading the same fixtures again leaves the races untouched.
+		fixtures, err := ReadFixtures("testdata/fixtures.json")
+		require.NoError(t, err)
+		require.NoError(t, LoadFixtures(racingDB, fixtures))
+
+		reloaded, err := racesRepo.Get(1)
+		require.NoError(t, err)
+		assert.Equal(t, race.Version, reloaded.Version)
+
+		// Races created afterwards are numbered after the loaded ones.
+		created, err := racesRepo.Create(&racing.Race{MeetingId: 1, Name: "Created", Number: 9, AdvertisedStartTime: race.AdvertisedStartTime})
+		require.NoError(t, err)
+		assert.Greater(t, created.Id, int64(100))
+	})
+}
+
+func TestSeed_Deterministic(t *testing.T) {
+	now := time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC)
+
+	seeded := func(seed int64) []*racing.Race {
+		racingDB := openDB(t)
+		migrateDB(t, racingDB)
+		require.NoError(t, Seed(racingDB, SeedOptions{Seed: seed, Now: now}))
+
+		races, _, err := NewRacesRepo(racingDB).List(&racing.ListRacesRequest{OrderBy: "id"})
+		require.NoError(t, err)
+
+		return races
+	}
+
+	equal := func(a, b []*racing.Race) bool {
+		if len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !proto.Equal(a[i], b[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	races := seeded(42)
+	assert.Len(t, races, 100)
+	assert.True(t, equal(races, seeded(42)), "Seeding with the same seed should produce the same races.")
+	assert.False(t, equal(races, seeded(43)), "Seeding with another seed should produce other races.")
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+)
+
+// ImportOutcome is what importing a race did to the stored races.
+type ImportOutcome int
+
+const (
+	// ImportCreated races didn't exist, and were created.
+	ImportCreated ImportOutcome = iota + 1
+	// ImportUpdated races existed, and were changed to match the import.
+	ImportUpdated
+	// ImportUnchanged races existed, and already matched the import.
+	ImportUnchanged
+)
+
+func (r *racesRepo) Import(races []*racing.Race) ([]ImportOutcome, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outcomes := make([]ImportOutcome, 0, len(races))
+	for _, race := range races {
+		outcome, err := r.importRace(tx, race)
+		if err != nil {
+			return nil, err
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return outcomes, nil
+}
+
+func (r *racesRepo) GetBySourceID(sourceID string) (*racing.Race, error) {
+	return r.getBySourceID(r.db, sourceID)
+}
+
+func (r *racesRepo) getBySourceID(q querier, sourceID string) (*racing.Race, error) {
+	rows, err := q.Query(r.dialect.rebind(getRaceQueries()[racesList]+" WHERE source_id = ?"), sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	races, err := r.scanRaces(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(races) == 0 {
+		return nil, ErrRaceNotFound
+	}
+
+	return races[0], nil
+}
+
+func (r *racesRepo) importRace(tx *sql.Tx, race *racing.Race) (ImportOutcome, error) {
+	current, err := r.getBySourceID(tx, race.SourceId)
+	if errors.Is(err, ErrRaceNotFound) {
+		_, err := r.dialect.insert(
+			tx,
+			`INSERT INTO races(meeting_id, name, number, visible, advertised_start_time, version, source_id) VALUES (?,?,?,?,?,1,?)`,
+			race.MeetingId,
+			race.Name,
+			race.Number,
+			race.Visible,
+			timeArg(race.AdvertisedStartTime.AsTime()),
+			race.SourceId,
+		)
+
+		return ImportCreated, err
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !raceChanged(current, race) {
+		return ImportUnchanged, nil
+	}
+
+	_, err = tx.Exec(
+		r.dialect.rebind(`UPDATE races SET meeting_id = ?, name = ?, number = ?, visible = ?, advertised_start_time = ?, version = version + 1 WHERE id = ?`),
+		race.MeetingId,
+		race.Name,
+		race.Number,
+		race.Visible,
+		timeArg(race.AdvertisedStartTime.AsTime()),
+		current.Id,
+	)
+
+	return ImportUpdated, err
+}
+
+// raceChanged reports whether any of the updatable fields of a race differ from the stored race, at
+// the precision they are stored in.
+func raceChanged(stored, race *racing.Race) bool {
+	for _, field := range RaceUpdatableFields() {
+		value := raceUpdateColumns[field].value
+		if value(stored) != value(race) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRacesRepo_Import(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := NewRacesRepo(racingDB)
+
+		races, err := ReadRaces("testdata/import.csv")
+		require.NoError(t, err)
+		require.Len(t, races, 2)
+
+		outcomes, err := racesRepo.Import(races)
+		require.NoError(t, err)
+		assert.Equal(t, []ImportOutcome{ImportCreated, ImportCreated}, outcomes)
+
+		// Importing the same races again doesn't create duplicates, or touch them.
+		outcomes, err = racesRepo.Import(races)
+		require.NoError(t, err)
+		assert.Equal(t, []ImportOutcome{ImportUnchanged, ImportUnchanged}, outcomes)
+
+		changed := proto.Clone(races[1]).(*racing.Race)
+		changed.Name = "Australian Cup (Group 1)"
+
+		outcomes, err = racesRepo.Import([]*racing.Race{races[0], changed})
+		require.NoError(t, err)
+		assert.Equal(t, []ImportOutcome{ImportUnchanged, ImportUpdated}, outcomes)
+
+		all, _, err := racesRepo.List(&racing.ListRacesRequest{OrderBy: "id"})
+		require.NoError(t, err)
+		require.Len(t, all, 102)
+
+		imported := all[len(all)-1]
+		assert.Equal(t, "form-2021-03-06-flemington-8", imported.SourceId)
+		assert.Equal(t, "Australian Cup (Group 1)", imported.Name)
+		assert.Equal(t, int64(2), imported.Version)
+		assert.Empty(t, all[0].SourceId)
+	})
+}
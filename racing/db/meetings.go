@@ -0,0 +1,135 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+)
+
+// ErrMeetingNotFound is returned when a meeting cannot be found for a given ID.
+var ErrMeetingNotFound = errors.New("meeting not found")
+
+// meetingDateLayout is the format meeting dates are stored and returned in.
+const meetingDateLayout = "2006-01-02"
+
+// MeetingsRepo provides repository access to meetings.
+type MeetingsRepo interface {
+	// List will return a list of meetings matching the filter.
+	List(filter *racing.ListMeetingsRequestFilter) ([]*racing.Meeting, error)
+
+	// Get will return a single meeting by its ID, or ErrMeetingNotFound if it doesn't exist.
+	Get(id int64) (*racing.Meeting, error)
+}
+
+type meetingsRepo struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewMeetingsRepo creates a new meetings repository.
+func NewMeetingsRepo(db *sql.DB) MeetingsRepo {
+	return &meetingsRepo{db: db, dialect: dialectOf(db)}
+}
+
+func (r *meetingsRepo) List(filter *racing.ListMeetingsRequestFilter) ([]*racing.Meeting, error) {
+	query := getMeetingQueries()[meetingsList]
+
+	clauses, args := r.applyFilter(filter)
+	if len(clauses) != 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	query += " ORDER BY date ASC, id ASC"
+
+	rows, err := r.db.Query(r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.scanMeetings(rows)
+}
+
+func (r *meetingsRepo) Get(id int64) (*racing.Meeting, error) {
+	query := getMeetingQueries()[meetingsList] + " WHERE id = ?"
+
+	rows, err := r.db.Query(r.dialect.rebind(query), id)
+	if err != nil {
+		return nil, err
+	}
+
+	meetings, err := r.scanMeetings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(meetings) == 0 {
+		return nil, ErrMeetingNotFound
+	}
+
+	return meetings[0], nil
+}
+
+// applyFilter translates the filter into WHERE clauses and their arguments.
+func (r *meetingsRepo) applyFilter(filter *racing.ListMeetingsRequestFilter) ([]string, []interface{}) {
+	var (
+		clauses []string
+		args    []interface{}
+	)
+
+	if filter == nil {
+		return clauses, args
+	}
+
+	if len(filter.Ids) > 0 {
+		clauses = append(clauses, "id IN ("+strings.Repeat("?,", len(filter.Ids)-1)+"?)")
+
+		for _, id := range filter.Ids {
+			args = append(args, id)
+		}
+	}
+
+	if len(filter.RaceTypes) > 0 {
+		clauses = append(clauses, "race_type IN ("+strings.Repeat("?,", len(filter.RaceTypes)-1)+"?)")
+
+		for _, raceType := range filter.RaceTypes {
+			args = append(args, int32(raceType))
+		}
+	}
+
+	if len(filter.Countries) > 0 {
+		clauses = append(clauses, "country IN ("+strings.Repeat("?,", len(filter.Countries)-1)+"?)")
+
+		for _, country := range filter.Countries {
+			args = append(args, strings.ToUpper(country))
+		}
+	}
+
+	return clauses, args
+}
+
+func (r *meetingsRepo) scanMeetings(
+	rows *sql.Rows,
+) ([]*racing.Meeting, error) {
+	var meetings []*racing.Meeting
+
+	for rows.Next() {
+		var meeting racing.Meeting
+		var raceType int32
+
+		if err := rows.Scan(&meeting.Id, &meeting.Venue, &meeting.Country, &raceType, &meeting.Date); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		meeting.RaceType = racing.Meeting_RaceType(raceType)
+
+		meetings = append(meetings, &meeting)
+	}
+
+	return meetings, nil
+}
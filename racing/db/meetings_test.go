@@ -0,0 +1,60 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeetingsRepoAll_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		meetingsRepo := createMeetingsRepo(t, racingDB)
+
+		meetings, err := meetingsRepo.List(&racing.ListMeetingsRequestFilter{})
+		assert.NoError(t, err)
+		assert.Equalf(t, 10, len(meetings), "There should be a total of 10 meetings in DB.")
+
+		for _, meeting := range meetings {
+			assert.NotEqual(t, racing.Meeting_RACE_TYPE_UNSPECIFIED, meeting.RaceType)
+			assert.Regexp(t, `^\d{4}-\d{2}-\d{2}$`, meeting.Date)
+		}
+	})
+}
+
+func TestMeetingsRepoFilter_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		meetingsRepo := createMeetingsRepo(t, racingDB)
+
+		filter := &racing.ListMeetingsRequestFilter{
+			Ids:       []int64{1, 2, 3, 4, 5},
+			RaceTypes: []racing.Meeting_RaceType{racing.Meeting_THOROUGHBRED, racing.Meeting_HARNESS},
+		}
+		meetings, err := meetingsRepo.List(filter)
+		assert.NoError(t, err)
+
+		for _, meeting := range meetings {
+			assert.Contains(t, filter.Ids, meeting.Id)
+			assert.Contains(t, filter.RaceTypes, meeting.RaceType)
+		}
+	})
+}
+
+func TestMeetingsRepo_Get(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		meetingsRepo := createMeetingsRepo(t, racingDB)
+
+		meeting, err := meetingsRepo.Get(7)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), meeting.Id)
+
+		_, err = meetingsRepo.Get(1000)
+		assert.ErrorIs(t, err, ErrMeetingNotFound)
+	})
+}
+
+func createMeetingsRepo(t *testing.T, racingDB *sql.DB) MeetingsRepo {
+	repo := NewMeetingsRepo(racingDB)
+	return repo
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+
+	"git.neds.sh/matty/entain/racing/migrate"
+)
+
+var (
+	// ErrSchemaBehind is returned when a database has migrations that haven't been applied.
+	ErrSchemaBehind = migrate.ErrSchemaBehind
+	// ErrSchemaAhead is returned when a database has migrations applied that this build doesn't know.
+	ErrSchemaAhead = migrate.ErrSchemaAhead
+	// ErrNoFTS5 is returned for SQLite databases when the driver was built without FTS5, which indexes
+	// races for search.
+	ErrNoFTS5 = errors.New("sqlite driver lacks FTS5, build with -tags sqlite_fts5")
+)
+
+// migrationFiles holds a directory of migrations per dialect, which share the same versions.
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// NewMigrator creates a new migrator applying the racing migrations of the given database's dialect.
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	if dialectOf(db) == postgresDialect {
+		return migrate.New(db, migrationFiles, "migrations/"+postgresDialect, migrate.Postgres)
+	}
+
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return nil, err
+	}
+
+	if !fts5 {
+		return nil, ErrNoFTS5
+	}
+
+	return migrate.New(db, migrationFiles, "migrations/"+sqliteDialect, migrate.SQLite)
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator_UpDown(t *testing.T) {
+	racingDB := openDB(t)
+
+	migrator, err := NewMigrator(racingDB)
+	require.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(), ErrSchemaBehind)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, int(migrator.Latest()))
+	assert.NoError(t, migrator.Check())
+
+	// Applying again is a no-op.
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.Down(1)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.Equal(t, migrator.Latest(), reverted[0].Version)
+
+	version, err := migrator.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, migrator.Latest()-1, version)
+	assert.ErrorIs(t, migrator.Check(), ErrSchemaBehind)
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.Len(t, statuses, int(migrator.Latest()))
+	assert.False(t, statuses[0].AppliedAt.IsZero())
+	assert.True(t, statuses[len(statuses)-1].AppliedAt.IsZero())
+
+	// Every down script has to undo its up script, so the schema can be rebuilt from scratch.
+	_, err = migrator.Down(len(statuses))
+	assert.NoError(t, err)
+
+	var tables int
+	assert.NoError(t, racingDB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations'`).Scan(&tables))
+	assert.Zero(t, tables)
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	assert.NoError(t, migrator.Check())
+}
+
+func TestMigrator_AdoptsLegacyDatabase(t *testing.T) {
+	racingDB := openDB(t)
+
+	// Databases created before migrations were introduced only have the races table, with start times
+	// stored with a UTC offset.
+	_, err := racingDB.Exec(`CREATE TABLE races (id INTEGER PRIMARY KEY, meeting_id INTEGER, name TEXT, number INTEGER, visible INTEGER, advertised_start_time DATETIME)`)
+	assert.NoError(t, err)
+	_, err = racingDB.Exec(`INSERT INTO races VALUES (1, 5, 'North Dakota foes', 2, 1, '2021-03-03T11:30:57+10:00')`)
+	assert.NoError(t, err)
+
+	migrator, err := NewMigrator(racingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	race, err := NewRacesRepo(racingDB).Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "North Dakota foes", race.Name)
+	assert.Equal(t, int64(1), race.Version)
+	assert.True(t, time.Date(2021, 3, 3, 1, 30, 57, 0, time.UTC).Equal(race.AdvertisedStartTime.AsTime()))
+
+	var stored string
+	assert.NoError(t, racingDB.QueryRow(`SELECT CAST(advertised_start_time AS TEXT) FROM races WHERE id = 1`).Scan(&stored))
+	assert.Equal(t, "2021-03-03T01:30:57Z", stored)
+}
+
+func TestMigrator_AdoptsLegacyVersionColumn(t *testing.T) {
+	racingDB := openDB(t)
+
+	// Databases started before migrations were introduced, but after races were versioned, have the
+	// version column already.
+	_, err := racingDB.Exec(`CREATE TABLE races (id INTEGER PRIMARY KEY, meeting_id INTEGER, name TEXT, number INTEGER, visible INTEGER, advertised_start_time DATETIME, version INTEGER NOT NULL DEFAULT 1)`)
+	assert.NoError(t, err)
+	_, err = racingDB.Exec(`INSERT INTO races VALUES (1, 5, 'North Dakota foes', 2, 1, '2021-03-03T01:30:57Z', 3)`)
+	assert.NoError(t, err)
+
+	migrator, err := NewMigrator(racingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	race, err := NewRacesRepo(racingDB).Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), race.Version)
+}
@@ -0,0 +1 @@
+CREATE TABLE races (id BIGSERIAL PRIMARY KEY, meeting_id BIGINT, name TEXT, number BIGINT, visible BOOLEAN, advertised_start_time TIMESTAMPTZ);
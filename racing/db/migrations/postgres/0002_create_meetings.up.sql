@@ -0,0 +1 @@
+CREATE TABLE meetings (id BIGINT PRIMARY KEY, venue TEXT, country TEXT, race_type INTEGER, date TEXT);
@@ -0,0 +1,2 @@
+CREATE TABLE runners (id BIGINT PRIMARY KEY, race_id BIGINT, number BIGINT, name TEXT, barrier BIGINT, jockey TEXT, trainer TEXT, weight DOUBLE PRECISION, scratched BOOLEAN);
+CREATE INDEX runners_race_id ON runners (race_id);
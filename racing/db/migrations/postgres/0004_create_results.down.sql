@@ -0,0 +1,2 @@
+DROP TABLE race_placings;
+DROP TABLE race_results;
@@ -0,0 +1,2 @@
+CREATE TABLE race_results (race_id BIGINT PRIMARY KEY, status INTEGER, resulted_time TIMESTAMPTZ);
+CREATE TABLE race_placings (race_id BIGINT, runner_id BIGINT, position BIGINT, PRIMARY KEY (race_id, runner_id));
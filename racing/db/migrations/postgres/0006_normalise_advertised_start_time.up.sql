@@ -0,0 +1,3 @@
+-- TIMESTAMPTZ columns store instants rather than text, so there is nothing to normalise. The migration
+-- is kept so both dialects share the same versions.
+SELECT 1;
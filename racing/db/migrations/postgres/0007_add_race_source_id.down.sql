@@ -0,0 +1,2 @@
+DROP INDEX races_source_id;
+ALTER TABLE races DROP COLUMN source_id;
@@ -0,0 +1,2 @@
+-- The sequence of the latest feed message applied to each race, so stale and duplicate messages are skipped.
+CREATE TABLE feed_sequences (source_id TEXT PRIMARY KEY, sequence BIGINT NOT NULL);
@@ -0,0 +1,3 @@
+-- Every price a runner has been offered at. A runner's current price is its latest.
+CREATE TABLE prices (id BIGSERIAL PRIMARY KEY, race_id BIGINT NOT NULL, runner_id BIGINT NOT NULL, win DOUBLE PRECISION NOT NULL, place DOUBLE PRECISION NOT NULL, updated_time TIMESTAMPTZ NOT NULL);
+CREATE INDEX prices_race_id ON prices (race_id, runner_id);
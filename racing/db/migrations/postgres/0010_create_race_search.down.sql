@@ -0,0 +1 @@
+DROP VIEW race_documents;
@@ -0,0 +1,9 @@
+-- The searchable text of each race: its name, its meeting's venue and its runners' names.
+CREATE VIEW race_documents AS
+    SELECT
+        races.id AS race_id,
+        COALESCE(races.name, '') AS name,
+        COALESCE(meetings.venue, '') AS venue,
+        COALESCE((SELECT string_agg(runners.name, ' ') FROM runners WHERE runners.race_id = races.id), '') AS runners
+    FROM races
+    LEFT JOIN meetings ON meetings.id = races.meeting_id;
@@ -0,0 +1,8 @@
+DROP TRIGGER race_search_runners ON runners;
+DROP TRIGGER race_search_meetings ON meetings;
+DROP TRIGGER race_search_races ON races;
+DROP FUNCTION race_search_runners_changed();
+DROP FUNCTION race_search_meetings_changed();
+DROP FUNCTION race_search_races_changed();
+DROP FUNCTION race_search_refresh(BIGINT[]);
+DROP TABLE race_search;
@@ -0,0 +1,70 @@
+-- A text search index of race documents, keyed by race ID. Each race's text search vector is stored
+-- with its document, so searches match it through the GIN index rather than computing it for every race.
+CREATE TABLE race_search (
+    race_id BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    venue TEXT NOT NULL,
+    runners TEXT NOT NULL,
+    document tsvector NOT NULL
+);
+
+CREATE INDEX race_search_document ON race_search USING GIN (document);
+
+-- race_search_refresh rebuilds the documents of the given races, dropping those of races that no longer
+-- exist.
+CREATE FUNCTION race_search_refresh(race_ids BIGINT[]) RETURNS void AS $$
+BEGIN
+    DELETE FROM race_search WHERE race_id = ANY(race_ids);
+    INSERT INTO race_search (race_id, name, venue, runners, document)
+        SELECT race_id, name, venue, runners, to_tsvector('simple', name || ' ' || venue || ' ' || runners)
+        FROM race_documents
+        WHERE race_id = ANY(race_ids);
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT race_search_refresh(ARRAY(SELECT id FROM races));
+
+-- The index is kept up to date with the races, meetings and runners it's built from.
+CREATE FUNCTION race_search_races_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM race_search_refresh(ARRAY[OLD.id]);
+    ELSE
+        PERFORM race_search_refresh(ARRAY[NEW.id]);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION race_search_meetings_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        PERFORM race_search_refresh(ARRAY(SELECT id FROM races WHERE meeting_id IN (OLD.id, NEW.id)));
+    ELSE
+        PERFORM race_search_refresh(ARRAY(SELECT id FROM races WHERE meeting_id = NEW.id));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION race_search_runners_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM race_search_refresh(ARRAY[OLD.race_id]);
+    ELSIF TG_OP = 'UPDATE' THEN
+        PERFORM race_search_refresh(ARRAY[OLD.race_id, NEW.race_id]);
+    ELSE
+        PERFORM race_search_refresh(ARRAY[NEW.race_id]);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER race_search_races AFTER INSERT OR UPDATE OR DELETE ON races
+    FOR EACH ROW EXECUTE PROCEDURE race_search_races_changed();
+
+CREATE TRIGGER race_search_meetings AFTER INSERT OR UPDATE ON meetings
+    FOR EACH ROW EXECUTE PROCEDURE race_search_meetings_changed();
+
+CREATE TRIGGER race_search_runners AFTER INSERT OR UPDATE OR DELETE ON runners
+    FOR EACH ROW EXECUTE PROCEDURE race_search_runners_changed();
@@ -0,0 +1,2 @@
+-- Databases created before migrations were introduced already have this table, which is adopted as is.
+CREATE TABLE IF NOT EXISTS races (id INTEGER PRIMARY KEY, meeting_id INTEGER, name TEXT, number INTEGER, visible INTEGER, advertised_start_time DATETIME);
@@ -0,0 +1 @@
+CREATE TABLE IF NOT EXISTS meetings (id INTEGER PRIMARY KEY, venue TEXT, country TEXT, race_type INTEGER, date TEXT);
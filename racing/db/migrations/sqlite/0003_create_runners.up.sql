@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS runners (id INTEGER PRIMARY KEY, race_id INTEGER, number INTEGER, name TEXT, barrier INTEGER, jockey TEXT, trainer TEXT, weight REAL, scratched INTEGER);
+CREATE INDEX IF NOT EXISTS runners_race_id ON runners (race_id);
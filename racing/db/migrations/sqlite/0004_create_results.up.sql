@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS race_results (race_id INTEGER PRIMARY KEY, status INTEGER, resulted_time DATETIME);
+CREATE TABLE IF NOT EXISTS race_placings (race_id INTEGER, runner_id INTEGER, position INTEGER, PRIMARY KEY (race_id, runner_id));
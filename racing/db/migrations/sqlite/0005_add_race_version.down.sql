@@ -0,0 +1,5 @@
+-- SQLite can't drop columns, so the table is rebuilt without it.
+CREATE TABLE races_unversioned (id INTEGER PRIMARY KEY, meeting_id INTEGER, name TEXT, number INTEGER, visible INTEGER, advertised_start_time DATETIME);
+INSERT INTO races_unversioned SELECT id, meeting_id, name, number, visible, advertised_start_time FROM races;
+DROP TABLE races;
+ALTER TABLE races_unversioned RENAME TO races;
@@ -0,0 +1,3 @@
+-- Databases started before migrations were introduced may have the column already, as it was added when the
+-- service started, which is adopted as is.
+ALTER TABLE races ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
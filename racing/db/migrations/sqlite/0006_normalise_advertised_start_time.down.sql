@@ -0,0 +1,2 @@
+-- The original formatting isn't needed by anything, so there is nothing to undo.
+SELECT 1;
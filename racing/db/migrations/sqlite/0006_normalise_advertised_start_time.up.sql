@@ -0,0 +1,5 @@
+-- Early databases stored start times with a UTC offset, e.g. "2021-03-03T11:30:57+10:00", which don't
+-- compare correctly against the RFC 3339 UTC times used everywhere else. Rewrite them in UTC.
+UPDATE races
+SET advertised_start_time = strftime('%Y-%m-%dT%H:%M:%SZ', advertised_start_time)
+WHERE advertised_start_time GLOB '*[+-][0-9][0-9]:[0-9][0-9]';
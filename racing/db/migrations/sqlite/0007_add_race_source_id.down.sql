@@ -0,0 +1,7 @@
+DROP INDEX races_source_id;
+
+-- SQLite can't drop columns, so the table is rebuilt without it.
+CREATE TABLE races_unsourced (id INTEGER PRIMARY KEY, meeting_id INTEGER, name TEXT, number INTEGER, visible INTEGER, advertised_start_time DATETIME, version INTEGER NOT NULL DEFAULT 1);
+INSERT INTO races_unsourced SELECT id, meeting_id, name, number, visible, advertised_start_time, version FROM races;
+DROP TABLE races;
+ALTER TABLE races_unsourced RENAME TO races;
@@ -0,0 +1,3 @@
+-- Imported races are identified by the ID their source gives them, so re-importing them updates them.
+ALTER TABLE races ADD COLUMN source_id TEXT;
+CREATE UNIQUE INDEX races_source_id ON races (source_id);
@@ -0,0 +1,3 @@
+-- Every price a runner has been offered at. A runner's current price is its latest.
+CREATE TABLE prices (id INTEGER PRIMARY KEY, race_id INTEGER NOT NULL, runner_id INTEGER NOT NULL, win REAL NOT NULL, place REAL NOT NULL, updated_time DATETIME NOT NULL);
+CREATE INDEX prices_race_id ON prices (race_id, runner_id);
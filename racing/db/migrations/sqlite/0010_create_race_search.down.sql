@@ -0,0 +1,11 @@
+DROP TRIGGER race_search_runners_delete;
+DROP TRIGGER race_search_runners_update;
+DROP TRIGGER race_search_runners_insert;
+DROP TRIGGER race_search_meetings_update;
+DROP TRIGGER race_search_meetings_insert;
+DROP TRIGGER race_search_races_delete;
+DROP TRIGGER race_search_races_update;
+DROP TRIGGER race_search_races_insert;
+DROP TABLE race_search_terms;
+DROP TABLE race_search;
+DROP VIEW race_documents;
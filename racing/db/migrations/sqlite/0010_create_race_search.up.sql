@@ -0,0 +1,54 @@
+-- The searchable text of each race: its name, its meeting's venue and its runners' names.
+CREATE VIEW race_documents AS
+    SELECT
+        races.id AS race_id,
+        COALESCE(races.name, '') AS name,
+        COALESCE(meetings.venue, '') AS venue,
+        COALESCE((SELECT group_concat(runners.name, ' ') FROM runners WHERE runners.race_id = races.id), '') AS runners
+    FROM races
+    LEFT JOIN meetings ON meetings.id = races.meeting_id;
+
+-- A full-text index of race documents, keyed by race ID, and its vocabulary for fuzzy matching.
+CREATE VIRTUAL TABLE race_search USING fts4(name, venue, runners);
+CREATE VIRTUAL TABLE race_search_terms USING fts4aux(race_search);
+
+INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents;
+
+-- The index is kept up to date with the races, meetings and runners it's built from.
+CREATE TRIGGER race_search_races_insert AFTER INSERT ON races BEGIN
+    INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = NEW.id;
+END;
+
+CREATE TRIGGER race_search_races_update AFTER UPDATE ON races BEGIN
+    DELETE FROM race_search WHERE docid = OLD.id;
+    INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = NEW.id;
+END;
+
+CREATE TRIGGER race_search_races_delete AFTER DELETE ON races BEGIN
+    DELETE FROM race_search WHERE docid = OLD.id;
+END;
+
+CREATE TRIGGER race_search_meetings_insert AFTER INSERT ON meetings BEGIN
+    DELETE FROM race_search WHERE docid IN (SELECT id FROM races WHERE meeting_id = NEW.id);
+    INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id IN (SELECT id FROM races WHERE meeting_id = NEW.id);
+END;
+
+CREATE TRIGGER race_search_meetings_update AFTER UPDATE ON meetings BEGIN
+    DELETE FROM race_search WHERE docid IN (SELECT id FROM races WHERE meeting_id IN (OLD.id, NEW.id));
+    INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id IN (SELECT id FROM races WHERE meeting_id IN (OLD.id, NEW.id));
+END;
+
+CREATE TRIGGER race_search_runners_insert AFTER INSERT ON runners BEGIN
+    DELETE FROM race_search WHERE docid = NEW.race_id;
+    INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = NEW.race_id;
+END;
+
+CREATE TRIGGER race_search_runners_update AFTER UPDATE ON runners BEGIN
+    DELETE FROM race_search WHERE docid IN (OLD.race_id, NEW.race_id);
+    INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id IN (OLD.race_id, NEW.race_id);
+END;
+
+CREATE TRIGGER race_search_runners_delete AFTER DELETE ON runners BEGIN
+    DELETE FROM race_search WHERE docid = OLD.race_id;
+    INSERT INTO race_search(docid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = OLD.race_id;
+END;
@@ -0,0 +1,58 @@
+-- Races are indexed with FTS5 rather than FTS4, and their vocabulary read with fts5vocab rather than
+-- fts4aux. The index is rebuilt from the race documents, keyed by race ID.
+DROP TRIGGER race_search_runners_delete;
+DROP TRIGGER race_search_runners_update;
+DROP TRIGGER race_search_runners_insert;
+DROP TRIGGER race_search_meetings_update;
+DROP TRIGGER race_search_meetings_insert;
+DROP TRIGGER race_search_races_delete;
+DROP TRIGGER race_search_races_update;
+DROP TRIGGER race_search_races_insert;
+DROP TABLE race_search_terms;
+DROP TABLE race_search;
+
+-- The unicode61 tokenizer lower cases letters beyond ASCII too, and keeps their diacritics, as search
+-- words do.
+CREATE VIRTUAL TABLE race_search USING fts5(name, venue, runners, tokenize = 'unicode61 remove_diacritics 0');
+CREATE VIRTUAL TABLE race_search_terms USING fts5vocab(race_search, 'row');
+
+INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents;
+
+-- The index is kept up to date with the races, meetings and runners it's built from.
+CREATE TRIGGER race_search_races_insert AFTER INSERT ON races BEGIN
+    INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = NEW.id;
+END;
+
+CREATE TRIGGER race_search_races_update AFTER UPDATE ON races BEGIN
+    DELETE FROM race_search WHERE rowid = OLD.id;
+    INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = NEW.id;
+END;
+
+CREATE TRIGGER race_search_races_delete AFTER DELETE ON races BEGIN
+    DELETE FROM race_search WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER race_search_meetings_insert AFTER INSERT ON meetings BEGIN
+    DELETE FROM race_search WHERE rowid IN (SELECT id FROM races WHERE meeting_id = NEW.id);
+    INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id IN (SELECT id FROM races WHERE meeting_id = NEW.id);
+END;
+
+CREATE TRIGGER race_search_meetings_update AFTER UPDATE ON meetings BEGIN
+    DELETE FROM race_search WHERE rowid IN (SELECT id FROM races WHERE meeting_id IN (OLD.id, NEW.id));
+    INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id IN (SELECT id FROM races WHERE meeting_id IN (OLD.id, NEW.id));
+END;
+
+CREATE TRIGGER race_search_runners_insert AFTER INSERT ON runners BEGIN
+    DELETE FROM race_search WHERE rowid = NEW.race_id;
+    INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = NEW.race_id;
+END;
+
+CREATE TRIGGER race_search_runners_update AFTER UPDATE ON runners BEGIN
+    DELETE FROM race_search WHERE rowid IN (OLD.race_id, NEW.race_id);
+    INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id IN (OLD.race_id, NEW.race_id);
+END;
+
+CREATE TRIGGER race_search_runners_delete AFTER DELETE ON runners BEGIN
+    DELETE FROM race_search WHERE rowid = OLD.race_id;
+    INSERT INTO race_search(rowid, name, venue, runners) SELECT race_id, name, venue, runners FROM race_documents WHERE race_id = OLD.race_id;
+END;
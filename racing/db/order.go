@@ -0,0 +1,131 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+)
+
+// ErrInvalidOrderBy is returned when an order_by clause references an unknown field or direction.
+var ErrInvalidOrderBy = errors.New("invalid order_by")
+
+// defaultRaceOrderBy is applied when the caller doesn't ask for a specific ordering.
+const defaultRaceOrderBy = "advertised_start_time"
+
+// orderColumn describes a sortable race field: the column it maps to, and how to read the value
+// the column holds off a race, which is what page cursors are built from.
+type orderColumn struct {
+	name  string
+	value func(race *racing.Race) interface{}
+}
+
+// raceOrderColumns whitelists the race fields that can be sorted on.
+var raceOrderColumns = map[string]orderColumn{
+	"id": {"id", func(race *racing.Race) interface{} {
+		return race.Id
+	}},
+	"meeting_id": {"meeting_id", func(race *racing.Race) interface{} {
+		return race.MeetingId
+	}},
+	"name": {"name", func(race *racing.Race) interface{} {
+		return race.Name
+	}},
+	"number": {"number", func(race *racing.Race) interface{} {
+		return race.Number
+	}},
+	"visible": {"visible", func(race *racing.Race) interface{} {
+		return race.Visible
+	}},
+	"advertised_start_time": {"advertised_start_time", func(race *racing.Race) interface{} {
+		return timeArg(race.AdvertisedStartTime.AsTime())
+	}},
+}
+
+// orderField is a single, validated, term of an ORDER BY clause.
+type orderField struct {
+	orderColumn
+	desc bool
+}
+
+// parseOrderBy validates an order_by value against the whitelisted race fields.
+// The race ID is always appended as a final tie-breaker so the ordering is total.
+func parseOrderBy(orderBy string) ([]orderField, error) {
+	if strings.TrimSpace(orderBy) == "" {
+		orderBy = defaultRaceOrderBy
+	}
+
+	var (
+		fields []orderField
+		seen   = map[string]bool{}
+	)
+
+	for _, term := range strings.Split(orderBy, ",") {
+		parts := strings.Fields(term)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("%w: malformed term %q", ErrInvalidOrderBy, strings.TrimSpace(term))
+		}
+
+		column, ok := raceOrderColumns[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidOrderBy, parts[0])
+		}
+
+		if seen[column.name] {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidOrderBy, parts[0])
+		}
+		seen[column.name] = true
+
+		field := orderField{orderColumn: column}
+		if len(parts) == 2 {
+			switch strings.ToLower(parts[1]) {
+			case "asc":
+			case "desc":
+				field.desc = true
+			default:
+				return nil, fmt.Errorf("%w: unknown direction %q", ErrInvalidOrderBy, parts[1])
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	if !seen["id"] {
+		fields = append(fields, orderField{orderColumn: raceOrderColumns["id"]})
+	}
+
+	return fields, nil
+}
+
+// orderByClause renders the validated fields as an SQL ORDER BY clause.
+func orderByClause(fields []orderField) string {
+	terms := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		direction := "ASC"
+		if field.desc {
+			direction = "DESC"
+		}
+
+		terms = append(terms, field.name+" "+direction)
+	}
+
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
+
+// orderByString renders the validated fields in the normalised order_by syntax.
+func orderByString(fields []orderField) string {
+	terms := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		direction := "asc"
+		if field.desc {
+			direction = "desc"
+		}
+
+		terms = append(terms, field.name+" "+direction)
+	}
+
+	return strings.Join(terms, ",")
+}
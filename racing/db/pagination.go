@@ -0,0 +1,167 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrInvalidPageSize is returned when a negative page size is requested.
+	ErrInvalidPageSize = errors.New("invalid page_size")
+
+	// ErrInvalidPageToken is returned when a page token can't be decoded, or was issued for a different request.
+	ErrInvalidPageToken = errors.New("invalid page_token")
+)
+
+// maxPageSize caps the number of races returned in a single page.
+const maxPageSize = 1000
+
+// pageCursor is the keyset cursor carried in page tokens. It records the sort key of the last race
+// returned, so the next page starts strictly after it regardless of rows inserted in the meantime.
+type pageCursor struct {
+	// OrderBy is the normalised ordering the cursor was built for.
+	OrderBy string `json:"o"`
+	// Filter is a fingerprint of the filter the cursor was built for.
+	Filter string `json:"f"`
+	// Values holds the last race's value for each ordered field.
+	Values []interface{} `json:"v"`
+}
+
+// pageSize validates the requested page size, returning zero when the results shouldn't be paginated.
+func pageSize(size int32) (int, error) {
+	if size < 0 {
+		return 0, fmt.Errorf("%w: must not be negative", ErrInvalidPageSize)
+	}
+
+	if size > maxPageSize {
+		return maxPageSize, nil
+	}
+
+	return int(size), nil
+}
+
+// filterFingerprint identifies a filter, so a token can't be replayed against a different one.
+func filterFingerprint(filter *racing.ListRacesRequestFilter) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(normaliseFilter(filter))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// normaliseFilter returns a copy of the filter in a canonical form, so filters selecting the same races
+// identify alike: meeting IDs are sorted, and duplicates dropped.
+func normaliseFilter(filter *racing.ListRacesRequestFilter) *racing.ListRacesRequestFilter {
+	if filter == nil {
+		return &racing.ListRacesRequestFilter{}
+	}
+
+	normalised := proto.Clone(filter).(*racing.ListRacesRequestFilter)
+
+	ids := append([]int64(nil), filter.MeetingIds...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	normalised.MeetingIds = nil
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			normalised.MeetingIds = append(normalised.MeetingIds, id)
+		}
+	}
+
+	return normalised
+}
+
+// encodePageToken builds an opaque page token pointing just past the given race.
+func encodePageToken(order []orderField, fingerprint string, last *racing.Race) (string, error) {
+	cursor := pageCursor{
+		OrderBy: orderByString(order),
+		Filter:  fingerprint,
+	}
+
+	for _, field := range order {
+		cursor.Values = append(cursor.Values, field.value(last))
+	}
+
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodePageToken parses a page token, checking it was issued for the same ordering and filter.
+func decodePageToken(token string, order []orderField, fingerprint string) (*pageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidPageToken)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(b)))
+	decoder.UseNumber()
+
+	var cursor pageCursor
+	if err := decoder.Decode(&cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidPageToken)
+	}
+
+	if cursor.OrderBy != orderByString(order) || cursor.Filter != fingerprint || len(cursor.Values) != len(order) {
+		return nil, fmt.Errorf("%w: token does not match the request", ErrInvalidPageToken)
+	}
+
+	// Numbers come back from JSON untyped; all numeric sort keys are integers.
+	for i, value := range cursor.Values {
+		if number, ok := value.(json.Number); ok {
+			n, err := number.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("%w: malformed token", ErrInvalidPageToken)
+			}
+
+			cursor.Values[i] = n
+		}
+	}
+
+	return &cursor, nil
+}
+
+// keysetClause builds the condition selecting the rows that sort strictly after the cursor, e.g. for
+// "a asc, b desc": (a > ?) OR (a = ? AND b < ?).
+func keysetClause(order []orderField, cursor *pageCursor) (string, []interface{}) {
+	var (
+		alternatives []string
+		args         []interface{}
+	)
+
+	for i, field := range order {
+		var terms []string
+
+		for j := 0; j < i; j++ {
+			terms = append(terms, order[j].name+" = ?")
+			args = append(args, cursor.Values[j])
+		}
+
+		comparison := " > ?"
+		if field.desc {
+			comparison = " < ?"
+		}
+
+		terms = append(terms, field.name+comparison)
+		args = append(args, cursor.Values[i])
+
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
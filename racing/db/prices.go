@@ -0,0 +1,147 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// PricesRepo provides repository access to runners' prices and their history.
+type PricesRepo interface {
+	// Current will return the latest price of each priced runner of the given races, ordered by race and
+	// runner.
+	Current(raceIDs []int64) ([]*racing.Price, error)
+
+	// List will return every price recorded for the runners of a race, ordered by runner and then by when
+	// each price was recorded.
+	List(raceID int64) ([]*racing.Price, error)
+
+	// Update will record new prices in a single transaction, timestamped with the current time. Prices
+	// matching the runner's current price aren't recorded again. It returns the prices it recorded.
+	Update(prices []*racing.Price) ([]*racing.Price, error)
+}
+
+type pricesRepo struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewPricesRepo creates a new prices repository.
+func NewPricesRepo(db *sql.DB) PricesRepo {
+	return &pricesRepo{db: db, dialect: dialectOf(db)}
+}
+
+func (r *pricesRepo) Current(raceIDs []int64) ([]*racing.Price, error) {
+	if len(raceIDs) == 0 {
+		return nil, nil
+	}
+
+	// A runner's latest price is the last one recorded for it.
+	query := getPriceQueries()[pricesList] +
+		" WHERE id IN (SELECT MAX(id) FROM prices WHERE race_id IN (" + strings.Repeat("?,", len(raceIDs)-1) + "?) GROUP BY runner_id)" +
+		" ORDER BY race_id ASC, runner_id ASC"
+
+	args := make([]interface{}, 0, len(raceIDs))
+	for _, raceID := range raceIDs {
+		args = append(args, raceID)
+	}
+
+	rows, err := r.db.Query(r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.scanPrices(rows)
+}
+
+func (r *pricesRepo) List(raceID int64) ([]*racing.Price, error) {
+	rows, err := r.db.Query(
+		r.dialect.rebind(getPriceQueries()[pricesList]+" WHERE race_id = ? ORDER BY runner_id ASC, id ASC"),
+		raceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.scanPrices(rows)
+}
+
+func (r *pricesRepo) Update(prices []*racing.Price) ([]*racing.Price, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var recorded []*racing.Price
+	for _, price := range prices {
+		var win, place float64
+
+		err := tx.QueryRow(
+			r.dialect.rebind(`SELECT win, place FROM prices WHERE runner_id = ? ORDER BY id DESC LIMIT 1`),
+			price.RunnerId,
+		).Scan(&win, &place)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if err == nil && win == price.Win && place == price.Place {
+			continue
+		}
+
+		if _, err := tx.Exec(
+			r.dialect.rebind(`INSERT INTO prices(race_id, runner_id, win, place, updated_time) VALUES (?,?,?,?,?)`),
+			price.RaceId,
+			price.RunnerId,
+			price.Win,
+			price.Place,
+			timeArg(now),
+		); err != nil {
+			return nil, err
+		}
+
+		recorded = append(recorded, &racing.Price{
+			RunnerId:    price.RunnerId,
+			RaceId:      price.RaceId,
+			Win:         price.Win,
+			Place:       price.Place,
+			UpdatedTime: timestamppb.New(now),
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+func (r *pricesRepo) scanPrices(
+	rows *sql.Rows,
+) ([]*racing.Price, error) {
+	defer rows.Close()
+
+	var prices []*racing.Price
+
+	for rows.Next() {
+		var (
+			price       racing.Price
+			updatedTime time.Time
+		)
+
+		if err := rows.Scan(&price.RunnerId, &price.RaceId, &price.Win, &price.Place, &updatedTime); err != nil {
+			return nil, err
+		}
+
+		price.UpdatedTime = timestamppb.New(updatedTime)
+
+		prices = append(prices, &price)
+	}
+
+	return prices, rows.Err()
+}
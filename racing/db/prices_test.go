@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricesRepo_Update(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		pricesRepo := createPricesRepo(t, racingDB)
+
+		// Seeded runners have an opening price.
+		opening, err := pricesRepo.Current([]int64{1})
+		require.NoError(t, err)
+		require.NotEmpty(t, opening)
+
+		runner := opening[0]
+
+		recorded, err := pricesRepo.Update([]*racing.Price{
+			{RaceId: 1, RunnerId: runner.RunnerId, Win: runner.Win, Place: runner.Place},
+			{RaceId: 1, RunnerId: runner.RunnerId, Win: 4.5, Place: 1.9},
+		})
+		require.NoError(t, err)
+
+		// Re-offering the current price isn't a movement.
+		if assert.Len(t, recorded, 1) {
+			assert.Equal(t, 4.5, recorded[0].Win)
+			assert.NotNil(t, recorded[0].UpdatedTime)
+		}
+
+		current, err := pricesRepo.Current([]int64{1})
+		require.NoError(t, err)
+		assert.Len(t, current, len(opening))
+
+		for _, price := range current {
+			if price.RunnerId == runner.RunnerId {
+				assert.Equal(t, 4.5, price.Win)
+				assert.Equal(t, 1.9, price.Place)
+			}
+		}
+
+		history, err := pricesRepo.List(1)
+		require.NoError(t, err)
+		assert.Len(t, history, len(opening)+1)
+
+		var moves []float64
+		for _, price := range history {
+			if price.RunnerId == runner.RunnerId {
+				moves = append(moves, price.Win)
+			}
+		}
+		assert.Equal(t, []float64{runner.Win, 4.5}, moves)
+	})
+}
+
+func TestPricesRepoNoRaces_Current(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		prices, err := createPricesRepo(t, racingDB).Current(nil)
+		assert.NoError(t, err)
+		assert.Empty(t, prices)
+	})
+}
+
+func createPricesRepo(t *testing.T, racingDB *sql.DB) PricesRepo {
+	repo := NewPricesRepo(racingDB)
+	return repo
+}
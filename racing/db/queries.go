@@ -1,7 +1,24 @@
 package db
 
+import (
+	"fmt"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+)
+
 const (
-	racesList = "list"
+	racesList    = "list"
+	meetingsList = "list"
+	runnersList  = "list"
+	resultsGet   = "get"
+	placingsList = "list"
+	pricesList   = "list"
+)
+
+// raceResultedExpr is true for races with an official result.
+var raceResultedExpr = fmt.Sprintf(
+	"EXISTS (SELECT 1 FROM race_results WHERE race_results.race_id = races.id AND race_results.status = %d)",
+	racing.RaceResult_OFFICIAL,
 )
 
 func getRaceQueries() map[string]string {
@@ -13,8 +30,78 @@ func getRaceQueries() map[string]string {
 				name, 
 				number, 
 				visible, 
-				advertised_start_time 
+				advertised_start_time, 
+				version, 
+				COALESCE(source_id, '') AS source_id, 
+				` + raceResultedExpr + ` AS resulted 
 			FROM races
 		`,
 	}
 }
+
+func getMeetingQueries() map[string]string {
+	return map[string]string{
+		meetingsList: `
+			SELECT 
+				id, 
+				venue, 
+				country, 
+				race_type, 
+				date 
+			FROM meetings
+		`,
+	}
+}
+
+func getRunnerQueries() map[string]string {
+	return map[string]string{
+		runnersList: `
+			SELECT 
+				id, 
+				race_id, 
+				number, 
+				name, 
+				barrier, 
+				jockey, 
+				trainer, 
+				weight, 
+				scratched 
+			FROM runners
+		`,
+	}
+}
+
+func getResultQueries() map[string]string {
+	return map[string]string{
+		resultsGet: `
+			SELECT 
+				race_id, 
+				status, 
+				resulted_time 
+			FROM race_results 
+			WHERE race_id = ?
+		`,
+		placingsList: `
+			SELECT 
+				runner_id, 
+				position 
+			FROM race_placings 
+			WHERE race_id = ? 
+			ORDER BY position ASC, runner_id ASC
+		`,
+	}
+}
+
+func getPriceQueries() map[string]string {
+	return map[string]string{
+		pricesList: `
+			SELECT 
+				runner_id, 
+				race_id, 
+				win, 
+				place, 
+				updated_time 
+			FROM prices
+		`,
+	}
+}
@@ -2,73 +2,344 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/golang/protobuf/ptypes"
 	_ "github.com/mattn/go-sqlite3"
 	"strings"
-	"sync"
 	"time"
 
 	"git.neds.sh/matty/entain/racing/proto/racing"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var (
+	// ErrRaceNotFound is returned when a race cannot be found for a given ID.
+	ErrRaceNotFound = errors.New("race not found")
+
+	// ErrInvalidFilter is returned when a filter's criteria contradict each other or are out of range.
+	ErrInvalidFilter = errors.New("invalid filter")
+
+	// ErrVersionConflict is returned when writing a race whose version has changed since it was read.
+	ErrVersionConflict = errors.New("race version conflict")
+
+	// ErrInvalidUpdateMask is returned when an update names a field that can't be updated.
+	ErrInvalidUpdateMask = errors.New("invalid update_mask")
+)
+
+// raceUpdateColumns maps the updatable race fields onto their column, and how to read their value off a race.
+var raceUpdateColumns = map[string]struct {
+	column string
+	value  func(race *racing.Race) interface{}
+}{
+	"meeting_id": {"meeting_id", func(race *racing.Race) interface{} {
+		return race.MeetingId
+	}},
+	"name": {"name", func(race *racing.Race) interface{} {
+		return race.Name
+	}},
+	"number": {"number", func(race *racing.Race) interface{} {
+		return race.Number
+	}},
+	"visible": {"visible", func(race *racing.Race) interface{} {
+		return race.Visible
+	}},
+	"advertised_start_time": {"advertised_start_time", func(race *racing.Race) interface{} {
+		return timeArg(race.AdvertisedStartTime.AsTime())
+	}},
+}
+
+// RaceUpdatableFields lists the race fields that can be named in an update.
+func RaceUpdatableFields() []string {
+	return []string{"meeting_id", "name", "number", "visible", "advertised_start_time"}
+}
+
 // RacesRepo provides repository access to races.
 type RacesRepo interface {
-	// Init will initialise our races repository.
-	Init() error
+	// List will return a page of races matching the request's filter, in the requested order, along
+	// with the token for the next page, which is empty on the last page.
+	List(in *racing.ListRacesRequest) ([]*racing.Race, string, error)
+
+	// Get will return a single race by its ID, or ErrRaceNotFound if it doesn't exist.
+	Get(id int64) (*racing.Race, error)
 
-	// List will return a list of races.
-	List(filter *racing.ListRacesRequestFilter) ([]*racing.Race, error)
+	// Create will insert a new race, returning it with its assigned ID and version.
+	Create(race *racing.Race) (*racing.Race, error)
+
+	// Update will write the named fields of a race, provided its version still matches the stored
+	// race, returning ErrVersionConflict otherwise. The race is returned with its new version.
+	Update(race *racing.Race, fields []string) (*racing.Race, error)
+
+	// Delete will remove a race, its runners, their prices and its result. When version is non-zero it must match the stored race.
+	Delete(id int64, version int64) error
+
+	// Search will return the races matching a text search, most relevant first, or ErrInvalidSearch
+	// if there's nothing to search for.
+	Search(in *racing.SearchRacesRequest) ([]*racing.Race, error)
+
+	// GetBySourceID will return a single race by its source ID, or ErrRaceNotFound if it doesn't exist.
+	GetBySourceID(sourceID string) (*racing.Race, error)
+
+	// Import will create or update races by their source ID, in a single transaction, returning what
+	// happened to each of them. Races that already match the stored race are left untouched.
+	Import(races []*racing.Race) ([]ImportOutcome, error)
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 type racesRepo struct {
-	db   *sql.DB
-	init sync.Once
+	db         *sql.DB
+	dialect    dialect
+	vocabulary vocabularyCache
 }
 
 // NewRacesRepo creates a new races repository.
 func NewRacesRepo(db *sql.DB) RacesRepo {
-	return &racesRepo{db: db}
+	return &racesRepo{db: db, dialect: dialectOf(db)}
 }
 
-// Init prepares the race repository dummy data.
-func (r *racesRepo) Init() error {
-	var err error
-
-	r.init.Do(func() {
-		// For test/example purposes, we seed the DB with some dummy races.
-		err = r.seed()
-	})
-
-	return err
-}
-
-func (r *racesRepo) List(filter *racing.ListRacesRequestFilter) ([]*racing.Race, error) {
+func (r *racesRepo) List(in *racing.ListRacesRequest) ([]*racing.Race, string, error) {
 	var (
 		err   error
 		query string
 		args  []interface{}
 	)
 
+	if err := validateFilter(in.GetFilter()); err != nil {
+		return nil, "", err
+	}
+
+	// Validate the ordering before building any SQL, so unknown fields never reach the query.
+	order, err := parseOrderBy(in.GetOrderBy())
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit, err := pageSize(in.GetPageSize())
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Next to jump is a single page of the soonest races, so it always uses the default ordering.
+	nextToJump := int(in.GetFilter().GetNextToJump())
+	if nextToJump > 0 {
+		if in.GetPageToken() != "" {
+			return nil, "", fmt.Errorf("%w: next_to_jump results are not paginated", ErrInvalidPageToken)
+		}
+
+		if order, err = parseOrderBy(""); err != nil {
+			return nil, "", err
+		}
+
+		limit = nextToJump
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+	}
+
+	fingerprint, err := filterFingerprint(in.GetFilter())
+	if err != nil {
+		return nil, "", err
+	}
+
 	query = getRaceQueries()[racesList]
 
-	query, args = r.applyFilter(query, filter)
+	clauses, args := r.applyFilter(in.GetFilter())
+
+	// Continue from where the previous page left off.
+	if in.GetPageToken() != "" {
+		cursor, err := decodePageToken(in.GetPageToken(), order, fingerprint)
+		if err != nil {
+			return nil, "", err
+		}
+
+		clause, cursorArgs := keysetClause(order, cursor)
+		clauses = append(clauses, clause)
+		args = append(args, cursorArgs...)
+	}
+
+	if len(clauses) != 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	query += orderByClause(order)
+
+	// Fetch one extra row to find out whether there is a next page.
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit+1)
+	}
+
+	rows, err := r.db.Query(r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	races, err := r.scanRaces(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if limit == 0 || len(races) <= limit {
+		return races, "", nil
+	}
+
+	races = races[:limit]
+
+	if nextToJump > 0 {
+		return races, "", nil
+	}
+
+	nextPageToken, err := encodePageToken(order, fingerprint, races[limit-1])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return races, nextPageToken, nil
+}
+
+func (r *racesRepo) Get(id int64) (*racing.Race, error) {
+	return r.get(r.db, id)
+}
+
+func (r *racesRepo) get(q querier, id int64) (*racing.Race, error) {
+	query := getRaceQueries()[racesList] + " WHERE id = ?"
+
+	rows, err := q.Query(r.dialect.rebind(query), id)
+	if err != nil {
+		return nil, err
+	}
+
+	races, err := r.scanRaces(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(races) == 0 {
+		return nil, ErrRaceNotFound
+	}
+
+	return races[0], nil
+}
+
+func (r *racesRepo) Create(race *racing.Race) (*racing.Race, error) {
+	id, err := r.dialect.insert(
+		r.db,
+		`INSERT INTO races(meeting_id, name, number, visible, advertised_start_time, version) VALUES (?,?,?,?,?,1)`,
+		race.MeetingId,
+		race.Name,
+		race.Number,
+		race.Visible,
+		timeArg(race.AdvertisedStartTime.AsTime()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Get(id)
+}
+
+func (r *racesRepo) Update(race *racing.Race, fields []string) (*racing.Race, error) {
+	var (
+		sets []string
+		args []interface{}
+	)
+
+	for _, field := range fields {
+		update, ok := raceUpdateColumns[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q can't be updated", ErrInvalidUpdateMask, field)
+		}
+
+		sets = append(sets, update.column+" = ?")
+		args = append(args, update.value(race))
+	}
 
-	rows, err := r.db.Query(query, args...)
+	// Bumping the version is what guards against concurrent writes, so it happens even without any fields.
+	sets = append(sets, "version = version + 1")
+	args = append(args, race.Id, race.Version)
+
+	res, err := r.db.Exec(r.dialect.rebind(`UPDATE races SET `+strings.Join(sets, ", ")+` WHERE id = ? AND version = ?`), args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.scanRaces(rows)
+	if err := r.checkWritten(r.db, res, race.Id); err != nil {
+		return nil, err
+	}
+
+	return r.Get(race.Id)
+}
+
+func (r *racesRepo) Delete(id int64, version int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, args := `DELETE FROM races WHERE id = ?`, []interface{}{id}
+	if version != 0 {
+		query += ` AND version = ?`
+		args = append(args, version)
+	}
+
+	res, err := tx.Exec(r.dialect.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+
+	if err := r.checkWritten(tx, res, id); err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM runners WHERE race_id = ?`,
+		`DELETE FROM race_placings WHERE race_id = ?`,
+		`DELETE FROM race_results WHERE race_id = ?`,
+		`DELETE FROM prices WHERE race_id = ?`,
+	} {
+		if _, err := tx.Exec(r.dialect.rebind(query), id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// checkWritten tells apart why a versioned write didn't touch any rows: the race is either gone, or
+// its version has moved on.
+func (r *racesRepo) checkWritten(q querier, res sql.Result, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected > 0 {
+		return nil
+	}
+
+	if _, err := r.get(q, id); err != nil {
+		return err
+	}
+
+	return ErrVersionConflict
 }
 
-func (r *racesRepo) applyFilter(query string, filter *racing.ListRacesRequestFilter) (string, []interface{}) {
+// applyFilter translates the filter into WHERE clauses and their arguments.
+func (r *racesRepo) applyFilter(filter *racing.ListRacesRequestFilter) ([]string, []interface{}) {
 	var (
 		clauses []string
 		args    []interface{}
 	)
 
 	if filter == nil {
-		return query, args
+		return clauses, args
 	}
 
 	if len(filter.MeetingIds) > 0 {
@@ -85,11 +356,73 @@ func (r *racesRepo) applyFilter(query string, filter *racing.ListRacesRequestFil
 		args = append(args, filter.Visible)
 	}
 
-	if len(clauses) != 0 {
-		query += " WHERE " + strings.Join(clauses, " AND ")
+	status := filter.Status
+
+	// Next to jump races are, by definition, the soonest races yet to jump.
+	if filter.NextToJump > 0 {
+		open := racing.Race_OPEN
+		status = &open
 	}
 
-	return query, args
+	// Status is derived from advertised_start_time and the race's result, so it is translated into a
+	// comparison against the current time, and a check for an official result.
+	if status != nil {
+		now := timeArg(time.Now())
+
+		switch *status {
+		case racing.Race_OPEN:
+			clauses = append(clauses, "advertised_start_time > ?")
+			args = append(args, now)
+		case racing.Race_CLOSED:
+			clauses = append(clauses, "advertised_start_time <= ? AND NOT "+raceResultedExpr)
+			args = append(args, now)
+		case racing.Race_RESULTED:
+			clauses = append(clauses, raceResultedExpr)
+		}
+	}
+
+	if filter.AdvertisedStartAfter != nil {
+		clauses = append(clauses, "advertised_start_time >= ?")
+		args = append(args, timeArg(filter.AdvertisedStartAfter.AsTime()))
+	}
+
+	if filter.AdvertisedStartBefore != nil {
+		clauses = append(clauses, "advertised_start_time < ?")
+		args = append(args, timeArg(filter.AdvertisedStartBefore.AsTime()))
+	}
+
+	return clauses, args
+}
+
+// validateFilter checks the filter's criteria before they are translated into SQL.
+func validateFilter(filter *racing.ListRacesRequestFilter) error {
+	if filter == nil {
+		return nil
+	}
+
+	if filter.NextToJump < 0 {
+		return fmt.Errorf("%w: next_to_jump must not be negative", ErrInvalidFilter)
+	}
+
+	for _, ts := range []*timestamppb.Timestamp{filter.AdvertisedStartAfter, filter.AdvertisedStartBefore} {
+		if ts != nil {
+			if err := ts.CheckValid(); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidFilter, err)
+			}
+		}
+	}
+
+	if filter.AdvertisedStartAfter != nil && filter.AdvertisedStartBefore != nil &&
+		!filter.AdvertisedStartAfter.AsTime().Before(filter.AdvertisedStartBefore.AsTime()) {
+		return fmt.Errorf("%w: advertised_start_after must be before advertised_start_before", ErrInvalidFilter)
+	}
+
+	return nil
+}
+
+// timeArg formats a time the way advertised start times are stored, so they compare correctly.
+func timeArg(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
 }
 
 func (m *racesRepo) scanRaces(
@@ -100,8 +433,9 @@ func (m *racesRepo) scanRaces(
 	for rows.Next() {
 		var race racing.Race
 		var advertisedStart time.Time
+		var resulted bool
 
-		if err := rows.Scan(&race.Id, &race.MeetingId, &race.Name, &race.Number, &race.Visible, &advertisedStart); err != nil {
+		if err := rows.Scan(&race.Id, &race.MeetingId, &race.Name, &race.Number, &race.Visible, &advertisedStart, &race.Version, &race.SourceId, &resulted); err != nil {
 			if err == sql.ErrNoRows {
 				return nil, nil
 			}
@@ -115,9 +449,23 @@ func (m *racesRepo) scanRaces(
 		}
 
 		race.AdvertisedStartTime = ts
+		race.Status = raceStatus(advertisedStart, resulted, time.Now())
 
 		races = append(races, &race)
 	}
 
 	return races, nil
 }
+
+// raceStatus derives the status of a race from its advertised start time, and whether it has an official result.
+func raceStatus(advertisedStart time.Time, resulted bool, now time.Time) racing.Race_Status {
+	if resulted {
+		return racing.Race_RESULTED
+	}
+
+	if advertisedStart.After(now) {
+		return racing.Race_OPEN
+	}
+
+	return racing.Race_CLOSED
+}
@@ -4,48 +4,278 @@ import (
 	"database/sql"
 	"git.neds.sh/matty/entain/racing/proto/racing"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"testing"
+	"time"
 )
 
 /*
 RacesRepo test class with different filters in ListRacesRequestFilter
 */
 func TestRacesRepoVisibleFilter_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
 
-	racesRepo := createRepo(t)
+		visible := true
+		// Set up a  filter with visibile true
+		filter := &racing.ListRacesRequestFilter{
+			Visible: &visible,
+		}
+		races, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: filter})
+		if err != nil {
+			return
+		}
 
-	visible := true
-	// Set up a  filter with visibile true
-	filter := &racing.ListRacesRequestFilter{
-		Visible: &visible,
-	}
-	races, err := racesRepo.List(filter)
-	if err != nil {
-		return
-	}
-
-	for _, race := range races {
-		assert.Truef(t, race.Visible, "Race %d is not visible.", race.Id)
-	}
+		for _, race := range races {
+			assert.Truef(t, race.Visible, "Race %d is not visible.", race.Id)
+		}
 
+	})
 }
 
 func TestRacesRepoAll_List(t *testing.T) {
-	racesRepo := createRepo(t)
-	// Set up a filter to pass to the List method
-	filter := &racing.ListRacesRequestFilter{}
-	races, err := racesRepo.List(filter)
-	assert.NoError(t, err)
-	assert.Equalf(t, 100, len(races), "There should be a total of 100 races in DB.")
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+		// Set up a filter to pass to the List method
+		filter := &racing.ListRacesRequestFilter{}
+		races, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: filter})
+		assert.NoError(t, err)
+		assert.Equalf(t, 100, len(races), "There should be a total of 100 races in DB.")
+	})
 }
 
-func createRepo(t *testing.T) RacesRepo {
-	racingDB, err := sql.Open("sqlite3", ":memory:")
-	assert.NoError(t, err)
+func TestRacesRepoStatusFilter_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
 
-	repo := NewRacesRepo(racingDB)
-	err = repo.Init()
-	assert.NoError(t, err)
+		for _, status := range []racing.Race_Status{racing.Race_OPEN, racing.Race_CLOSED} {
+			status := status
+			filter := &racing.ListRacesRequestFilter{
+				Status: &status,
+			}
+			races, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: filter})
+			assert.NoError(t, err)
+
+			for _, race := range races {
+				assert.Equalf(t, status, race.Status, "Race %d has unexpected status.", race.Id)
+			}
+		}
+	})
+}
+
+func TestRacesRepoDefaultOrder_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		races, _, err := racesRepo.List(&racing.ListRacesRequest{})
+		assert.NoError(t, err)
+
+		for i := 1; i < len(races); i++ {
+			prev, curr := races[i-1].AdvertisedStartTime.AsTime(), races[i].AdvertisedStartTime.AsTime()
+			assert.Falsef(t, curr.Before(prev), "Race %d starts before race %d.", races[i].Id, races[i-1].Id)
+		}
+	})
+}
+
+func TestRacesRepoOrderBy_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		races, _, err := racesRepo.List(&racing.ListRacesRequest{OrderBy: "meeting_id desc, number"})
+		assert.NoError(t, err)
+
+		for i := 1; i < len(races); i++ {
+			prev, curr := races[i-1], races[i]
+			assert.GreaterOrEqual(t, prev.MeetingId, curr.MeetingId)
+			if prev.MeetingId == curr.MeetingId {
+				assert.LessOrEqual(t, prev.Number, curr.Number)
+			}
+		}
+	})
+}
+
+func TestRacesRepoInvalidOrderBy_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		for _, orderBy := range []string{"unknown", "number sideways", "name; DROP TABLE races", "number, number", ","} {
+			_, _, err := racesRepo.List(&racing.ListRacesRequest{OrderBy: orderBy})
+			assert.ErrorIsf(t, err, ErrInvalidOrderBy, "order_by %q should be rejected.", orderBy)
+		}
+	})
+}
 
+func TestRacesRepoPagination_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		visible := true
+		filter := &racing.ListRacesRequestFilter{Visible: &visible}
+
+		for _, orderBy := range []string{"", "advertised_start_time desc", "meeting_id, number desc", "visible desc, name"} {
+			all, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: filter, OrderBy: orderBy})
+			assert.NoError(t, err)
+
+			var (
+				paged []*racing.Race
+				token string
+			)
+
+			for {
+				races, next, err := racesRepo.List(&racing.ListRacesRequest{Filter: filter, OrderBy: orderBy, PageSize: 7, PageToken: token})
+				assert.NoError(t, err)
+				assert.LessOrEqual(t, len(races), 7)
+
+				paged = append(paged, races...)
+				if next == "" {
+					break
+				}
+				token = next
+			}
+
+			assert.Equalf(t, all, paged, "Pages for order_by %q should add up to the full listing.", orderBy)
+		}
+	})
+}
+
+func TestRacesRepoInvalidPageToken_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		_, token, err := racesRepo.List(&racing.ListRacesRequest{PageSize: 5})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+
+		// Tokens are bound to the ordering and filter they were issued for.
+		_, _, err = racesRepo.List(&racing.ListRacesRequest{PageSize: 5, PageToken: token, OrderBy: "number"})
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+
+		visible := true
+		_, _, err = racesRepo.List(&racing.ListRacesRequest{PageSize: 5, PageToken: token, Filter: &racing.ListRacesRequestFilter{Visible: &visible}})
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+
+		_, _, err = racesRepo.List(&racing.ListRacesRequest{PageSize: 5, PageToken: "not a token"})
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+
+		_, _, err = racesRepo.List(&racing.ListRacesRequest{PageSize: -1})
+		assert.ErrorIs(t, err, ErrInvalidPageSize)
+	})
+}
+
+func TestRacesRepoTimeWindowFilter_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		after, before := time.Now().Add(-6*time.Hour), time.Now().Add(18*time.Hour)
+		filter := &racing.ListRacesRequestFilter{
+			AdvertisedStartAfter:  timestamppb.New(after),
+			AdvertisedStartBefore: timestamppb.New(before),
+		}
+		races, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: filter})
+		assert.NoError(t, err)
+
+		for _, race := range races {
+			start := race.AdvertisedStartTime.AsTime()
+			assert.Falsef(t, start.Before(after.Truncate(time.Second)), "Race %d starts before the window.", race.Id)
+			assert.Truef(t, start.Before(before), "Race %d starts after the window.", race.Id)
+		}
+
+		filter.AdvertisedStartAfter, filter.AdvertisedStartBefore = filter.AdvertisedStartBefore, filter.AdvertisedStartAfter
+		_, _, err = racesRepo.List(&racing.ListRacesRequest{Filter: filter})
+		assert.ErrorIs(t, err, ErrInvalidFilter)
+	})
+}
+
+func TestRacesRepoNextToJump_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		open := racing.Race_OPEN
+		all, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: &racing.ListRacesRequestFilter{Status: &open}})
+		assert.NoError(t, err)
+
+		races, next, err := racesRepo.List(&racing.ListRacesRequest{
+			Filter:  &racing.ListRacesRequestFilter{NextToJump: 5},
+			OrderBy: "number desc",
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, next)
+		assert.Equal(t, all[:5], races)
+	})
+}
+
+func TestRaceStatus(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, racing.Race_OPEN, raceStatus(now.Add(time.Minute), false, now))
+	assert.Equal(t, racing.Race_CLOSED, raceStatus(now.Add(-time.Minute), false, now))
+	assert.Equal(t, racing.Race_CLOSED, raceStatus(now, false, now))
+	assert.Equal(t, racing.Race_RESULTED, raceStatus(now.Add(-time.Minute), true, now))
+}
+
+func TestRacesRepo_Get(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		race, err := racesRepo.Get(42)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), race.Id)
+	})
+}
+
+func TestRacesRepoNotFound_Get(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		race, err := racesRepo.Get(1000)
+		assert.ErrorIs(t, err, ErrRaceNotFound)
+		assert.Nil(t, race)
+	})
+}
+
+func TestRacesRepo_Write(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+
+		start := time.Now().Add(time.Hour).Truncate(time.Second)
+		race, err := racesRepo.Create(&racing.Race{
+			MeetingId:           3,
+			Name:                "Maiden Plate",
+			Number:              4,
+			Visible:             true,
+			AdvertisedStartTime: timestamppb.New(start),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(101), race.Id)
+		assert.Equal(t, int64(1), race.Version)
+		assert.Equal(t, racing.Race_OPEN, race.Status)
+		assert.True(t, start.Equal(race.AdvertisedStartTime.AsTime()))
+
+		race.Name = "Maiden Handicap"
+		race.Number = 5
+		updated, err := racesRepo.Update(race, []string{"name"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Maiden Handicap", updated.Name)
+		assert.Equal(t, int64(4), updated.Number, "Fields outside the update should be left alone.")
+		assert.Equal(t, int64(2), updated.Version)
+
+		// The race has moved on to version 2, so writes based on version 1 are rejected.
+		_, err = racesRepo.Update(race, []string{"number"})
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		assert.ErrorIs(t, racesRepo.Delete(race.Id, race.Version), ErrVersionConflict)
+
+		_, err = racesRepo.Update(updated, []string{"version"})
+		assert.ErrorIs(t, err, ErrInvalidUpdateMask)
+
+		assert.NoError(t, racesRepo.Delete(updated.Id, updated.Version))
+
+		_, err = racesRepo.Get(updated.Id)
+		assert.ErrorIs(t, err, ErrRaceNotFound)
+		assert.ErrorIs(t, racesRepo.Delete(updated.Id, 0), ErrRaceNotFound)
+	})
+}
+
+func createRepo(t *testing.T, racingDB *sql.DB) RacesRepo {
+	repo := NewRacesRepo(racingDB)
 	return repo
 }
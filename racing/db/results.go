@@ -0,0 +1,155 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// ErrResultNotFound is returned when a race hasn't been resulted yet.
+	ErrResultNotFound = errors.New("result not found")
+
+	// ErrResultOfficial is returned when amending a result that has already been declared official.
+	ErrResultOfficial = errors.New("result is already official")
+)
+
+// ResultsRepo provides repository access to race results.
+type ResultsRepo interface {
+	// Get will return the result of a race, or ErrResultNotFound if it hasn't been resulted.
+	Get(raceID int64) (*racing.RaceResult, error)
+
+	// Save will record the result of a race, replacing any previous placings. It returns
+	// ErrResultOfficial if the race's result is already official.
+	Save(result *racing.RaceResult) (*racing.RaceResult, error)
+}
+
+type resultsRepo struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewResultsRepo creates a new results repository.
+func NewResultsRepo(db *sql.DB) ResultsRepo {
+	return &resultsRepo{db: db, dialect: dialectOf(db)}
+}
+
+func (r *resultsRepo) Get(raceID int64) (*racing.RaceResult, error) {
+	return r.get(r.db, raceID)
+}
+
+func (r *resultsRepo) Save(result *racing.RaceResult) (*racing.RaceResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := r.get(tx, result.RaceId)
+	if err != nil && !errors.Is(err, ErrResultNotFound) {
+		return nil, err
+	}
+
+	if current != nil && current.Status == racing.RaceResult_OFFICIAL {
+		return nil, ErrResultOfficial
+	}
+
+	if _, err := tx.Exec(
+		r.dialect.rebind(`INSERT INTO race_results(race_id, status, resulted_time) VALUES (?,?,?)
+			ON CONFLICT (race_id) DO UPDATE SET status = excluded.status, resulted_time = excluded.resulted_time`),
+		result.RaceId,
+		int32(result.Status),
+		timeArg(time.Now()),
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(r.dialect.rebind(`DELETE FROM race_placings WHERE race_id = ?`), result.RaceId); err != nil {
+		return nil, err
+	}
+
+	for _, placing := range result.Placings {
+		if _, err := tx.Exec(
+			r.dialect.rebind(`INSERT INTO race_placings(race_id, runner_id, position) VALUES (?,?,?)`),
+			result.RaceId,
+			placing.RunnerId,
+			placing.Position,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	saved, err := r.get(tx, result.RaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+func (r *resultsRepo) get(q querier, raceID int64) (*racing.RaceResult, error) {
+	var (
+		result       racing.RaceResult
+		status       int32
+		resultedTime time.Time
+	)
+
+	err := q.QueryRow(r.dialect.rebind(getResultQueries()[resultsGet]), raceID).Scan(&result.RaceId, &status, &resultedTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrResultNotFound
+		}
+
+		return nil, err
+	}
+
+	result.Status = racing.RaceResult_Status(status)
+	result.ResultedTime = timestamppb.New(resultedTime)
+
+	rows, err := q.Query(r.dialect.rebind(getResultQueries()[placingsList]), raceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Placings, err = r.scanPlacings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *resultsRepo) scanPlacings(
+	rows *sql.Rows,
+) ([]*racing.Placing, error) {
+	var placings []*racing.Placing
+
+	for rows.Next() {
+		var placing racing.Placing
+
+		if err := rows.Scan(&placing.RunnerId, &placing.Position); err != nil {
+			return nil, err
+		}
+
+		placings = append(placings, &placing)
+	}
+
+	// Runners sharing a position dead heated.
+	shared := map[int64]int{}
+	for _, placing := range placings {
+		shared[placing.Position]++
+	}
+
+	for _, placing := range placings {
+		placing.DeadHeat = shared[placing.Position] > 1
+	}
+
+	return placings, nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultsRepo_Save(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := NewRacesRepo(racingDB)
+		resultsRepo := NewResultsRepo(racingDB)
+
+		_, err := resultsRepo.Get(1)
+		assert.ErrorIs(t, err, ErrResultNotFound)
+
+		result, err := resultsRepo.Save(&racing.RaceResult{
+			RaceId: 1,
+			Status: racing.RaceResult_INTERIM,
+			Placings: []*racing.Placing{
+				{RunnerId: 3, Position: 3},
+				{RunnerId: 1, Position: 1},
+				{RunnerId: 2, Position: 1},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, racing.RaceResult_INTERIM, result.Status)
+		assert.NotNil(t, result.ResultedTime)
+		if assert.Len(t, result.Placings, 3) {
+			assert.Equal(t, int64(1), result.Placings[0].RunnerId)
+			assert.True(t, result.Placings[0].DeadHeat)
+			assert.True(t, result.Placings[1].DeadHeat)
+			assert.False(t, result.Placings[2].DeadHeat)
+		}
+
+		// Interim results don't result the race.
+		race, err := racesRepo.Get(1)
+		assert.NoError(t, err)
+		assert.NotEqual(t, racing.Race_RESULTED, race.Status)
+
+		// Amending the result replaces its placings.
+		result, err = resultsRepo.Save(&racing.RaceResult{
+			RaceId:   1,
+			Status:   racing.RaceResult_OFFICIAL,
+			Placings: []*racing.Placing{{RunnerId: 2, Position: 1}, {RunnerId: 1, Position: 2}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, result.Placings, 2)
+
+		race, err = racesRepo.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, racing.Race_RESULTED, race.Status)
+
+		resulted := racing.Race_RESULTED
+		races, _, err := racesRepo.List(&racing.ListRacesRequest{Filter: &racing.ListRacesRequestFilter{Status: &resulted}})
+		assert.NoError(t, err)
+		if assert.Len(t, races, 1) {
+			assert.Equal(t, int64(1), races[0].Id)
+		}
+
+		// Official results are final.
+		_, err = resultsRepo.Save(&racing.RaceResult{RaceId: 1, Status: racing.RaceResult_PROTEST})
+		assert.ErrorIs(t, err, ErrResultOfficial)
+	})
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+)
+
+// RunnersRepo provides repository access to runners.
+type RunnersRepo interface {
+	// List will return the runners of the given races, ordered by race and runner number.
+	List(raceIDs []int64) ([]*racing.Runner, error)
+}
+
+type runnersRepo struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewRunnersRepo creates a new runners repository.
+func NewRunnersRepo(db *sql.DB) RunnersRepo {
+	return &runnersRepo{db: db, dialect: dialectOf(db)}
+}
+
+func (r *runnersRepo) List(raceIDs []int64) ([]*racing.Runner, error) {
+	if len(raceIDs) == 0 {
+		return nil, nil
+	}
+
+	// The runners of every race are loaded in a single query, however many races there are.
+	query := getRunnerQueries()[runnersList] +
+		" WHERE race_id IN (" + strings.Repeat("?,", len(raceIDs)-1) + "?)" +
+		" ORDER BY race_id ASC, number ASC"
+
+	args := make([]interface{}, 0, len(raceIDs))
+	for _, raceID := range raceIDs {
+		args = append(args, raceID)
+	}
+
+	rows, err := r.db.Query(r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.scanRunners(rows)
+}
+
+func (r *runnersRepo) scanRunners(
+	rows *sql.Rows,
+) ([]*racing.Runner, error) {
+	var runners []*racing.Runner
+
+	for rows.Next() {
+		var runner racing.Runner
+
+		if err := rows.Scan(&runner.Id, &runner.RaceId, &runner.Number, &runner.Name, &runner.Barrier, &runner.Jockey, &runner.Trainer, &runner.Weight, &runner.Scratched); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		runners = append(runners, &runner)
+	}
+
+	return runners, nil
+}
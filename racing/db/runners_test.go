@@ -0,0 +1,50 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnersRepo_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		runnersRepo := createRunnersRepo(t, racingDB)
+
+		raceIDs := []int64{3, 1, 2}
+		runners, err := runnersRepo.List(raceIDs)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, runners)
+
+		counts := map[int64]int{}
+		for i, runner := range runners {
+			assert.Contains(t, raceIDs, runner.RaceId)
+			counts[runner.RaceId]++
+
+			// Runners come back grouped by race, in number order.
+			if i > 0 {
+				prev := runners[i-1]
+				assert.True(t, prev.RaceId < runner.RaceId || (prev.RaceId == runner.RaceId && prev.Number < runner.Number))
+			}
+		}
+
+		for _, raceID := range raceIDs {
+			assert.GreaterOrEqualf(t, counts[raceID], 6, "Race %d should have a field of runners.", raceID)
+		}
+	})
+}
+
+func TestRunnersRepoNoRaces_List(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		runnersRepo := createRunnersRepo(t, racingDB)
+
+		runners, err := runnersRepo.List(nil)
+		assert.NoError(t, err)
+		assert.Empty(t, runners)
+	})
+}
+
+func createRunnersRepo(t *testing.T, racingDB *sql.DB) RunnersRepo {
+	repo := NewRunnersRepo(racingDB)
+	return repo
+}
@@ -0,0 +1,391 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+)
+
+// ErrInvalidSearch is returned when a search has nothing to search for.
+var ErrInvalidSearch = errors.New("invalid search")
+
+const (
+	// defaultSearchSize is the number of races a search returns when no page size is given.
+	defaultSearchSize = 20
+	// maxSearchTerms caps the number of words searched for.
+	maxSearchTerms = 10
+	// maxSearchCandidates caps the number of races ranked per search: the races matching every term that
+	// the index itself rates most relevant.
+	maxSearchCandidates = 500
+	// vocabularyTTL is how long the search index's vocabulary is kept before it's read again.
+	vocabularyTTL = time.Minute
+)
+
+// Relevance of a word matching a search term, by how it matches, and by the field it matches in.
+const (
+	exactMatch  = 1.0
+	prefixMatch = 0.6
+	fuzzyMatch  = 0.3
+
+	nameWeight    = 3
+	venueWeight   = 2
+	runnersWeight = 1
+)
+
+// raceDocument is the searchable text of a race.
+type raceDocument struct {
+	raceID          int64
+	name            string
+	venue           string
+	runners         string
+	advertisedStart time.Time
+	score           float64
+}
+
+// vocabularyCache holds the words of the search index, which search words are fuzzily matched against.
+// Reading them all is too costly to do for every search, so they're read again at most once every
+// vocabularyTTL: words new to the index match exactly and by prefix straight away, and fuzzily once the
+// vocabulary is next read.
+type vocabularyCache struct {
+	mu    sync.Mutex
+	words []string
+	read  time.Time
+}
+
+// searchTerm is a word searched for, along with the indexed words that are a typo away from it.
+type searchTerm struct {
+	word  string
+	fuzzy map[string]bool
+}
+
+func (r *racesRepo) Search(in *racing.SearchRacesRequest) ([]*racing.Race, error) {
+	words := searchWords(in.GetQ())
+	if len(words) == 0 {
+		return nil, fmt.Errorf("%w: q must contain at least one word", ErrInvalidSearch)
+	}
+
+	if len(words) > maxSearchTerms {
+		return nil, fmt.Errorf("%w: q must contain at most %d words", ErrInvalidSearch, maxSearchTerms)
+	}
+
+	limit, err := pageSize(in.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+
+	if limit == 0 {
+		limit = defaultSearchSize
+	}
+
+	vocabulary, err := r.searchVocabulary()
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]searchTerm, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, searchTerm{word: word, fuzzy: fuzzyMatches(word, vocabulary)})
+	}
+
+	documents, err := r.searchDocuments(terms, in.Visible)
+	if err != nil {
+		return nil, err
+	}
+
+	// The index narrows down the candidates, which are ranked here, so every backend ranks them alike.
+	var ranked []*raceDocument
+	for _, document := range documents {
+		if document.score = rankDocument(document, terms); document.score > 0 {
+			ranked = append(ranked, document)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+
+		if !a.advertisedStart.Equal(b.advertisedStart) {
+			return a.advertisedStart.Before(b.advertisedStart)
+		}
+
+		return a.raceID < b.raceID
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	return r.getRanked(ranked)
+}
+
+// searchVocabulary returns every word in the search index, as of at most vocabularyTTL ago.
+func (r *racesRepo) searchVocabulary() ([]string, error) {
+	r.vocabulary.mu.Lock()
+	defer r.vocabulary.mu.Unlock()
+
+	if r.vocabulary.words != nil && time.Since(r.vocabulary.read) < vocabularyTTL {
+		return r.vocabulary.words, nil
+	}
+
+	query := `SELECT term FROM race_search_terms`
+	if r.dialect == postgresDialect {
+		query = `SELECT word FROM ts_stat('SELECT document FROM race_search')`
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := []string{}
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+
+		words = append(words, word)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	r.vocabulary.words, r.vocabulary.read = words, time.Now()
+
+	return words, nil
+}
+
+// searchDocuments returns the documents of the races matching every term, by prefix or by one of its
+// fuzzy matches, at most maxSearchCandidates of them, the most relevant first by the index's reckoning.
+func (r *racesRepo) searchDocuments(terms []searchTerm, visible *bool) ([]*raceDocument, error) {
+	// Postgres' text search and SQLite's full-text index spell their match expressions differently.
+	prefix, or, and := "*", " OR ", " AND "
+	query := `SELECT race_search.rowid, race_search.name, race_search.venue, race_search.runners, races.advertised_start_time
+		FROM race_search JOIN races ON races.id = race_search.rowid
+		WHERE race_search MATCH ?`
+	// bm25 weighs matches in each column as the ranking does.
+	order := fmt.Sprintf(` ORDER BY bm25(race_search, %d, %d, %d), races.id`, nameWeight, venueWeight, runnersWeight)
+
+	if r.dialect == postgresDialect {
+		prefix, or, and = ":*", " | ", " & "
+		query = `SELECT race_search.race_id, race_search.name, race_search.venue, race_search.runners, races.advertised_start_time
+			FROM race_search JOIN races ON races.id = race_search.race_id, to_tsquery('simple', ?) AS query
+			WHERE race_search.document @@ query`
+		order = ` ORDER BY ts_rank(race_search.document, query) DESC, races.id`
+	}
+
+	// Each term is a group of patterns, any of which it matches, and every group has to match. Search
+	// words only ever hold letters and digits, so they're safe to use in a match expression.
+	groups := make([]string, 0, len(terms))
+	for _, term := range terms {
+		patterns := []string{term.word + prefix}
+		for word := range term.fuzzy {
+			patterns = append(patterns, word)
+		}
+
+		groups = append(groups, "("+strings.Join(patterns, or)+")")
+	}
+
+	args := []interface{}{strings.Join(groups, and)}
+
+	if visible != nil {
+		query += " AND races.visible = ?"
+		args = append(args, *visible)
+	}
+
+	query += order + " LIMIT ?"
+	args = append(args, maxSearchCandidates)
+
+	rows, err := r.db.Query(r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []*raceDocument
+	for rows.Next() {
+		var document raceDocument
+		if err := rows.Scan(&document.raceID, &document.name, &document.venue, &document.runners, &document.advertisedStart); err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, &document)
+	}
+
+	return documents, rows.Err()
+}
+
+// getRanked loads the races of ranked documents, in rank order.
+func (r *racesRepo) getRanked(ranked []*raceDocument) ([]*racing.Race, error) {
+	if len(ranked) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(ranked))
+	for _, document := range ranked {
+		args = append(args, document.raceID)
+	}
+
+	rows, err := r.db.Query(
+		r.dialect.rebind(getRaceQueries()[racesList]+" WHERE id IN ("+strings.Repeat("?,", len(ranked)-1)+"?)"),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	races, err := r.scanRaces(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*racing.Race, len(races))
+	for _, race := range races {
+		byID[race.Id] = race
+	}
+
+	ordered := make([]*racing.Race, 0, len(races))
+	for _, document := range ranked {
+		if race, ok := byID[document.raceID]; ok {
+			ordered = append(ordered, race)
+		}
+	}
+
+	return ordered, nil
+}
+
+// rankDocument scores how relevant a document is to the search. Every term must match a word of the
+// document, and scores by its best match: exact matches score over prefix matches, which score over
+// fuzzy matches, and matches in the race's name score over its venue, which score over its runners.
+// Documents missing a term score zero.
+func rankDocument(document *raceDocument, terms []searchTerm) float64 {
+	fields := []struct {
+		words  []string
+		weight float64
+	}{
+		{searchWords(document.name), nameWeight},
+		{searchWords(document.venue), venueWeight},
+		{searchWords(document.runners), runnersWeight},
+	}
+
+	var score float64
+	for _, term := range terms {
+		var best float64
+
+		for _, field := range fields {
+			for _, word := range field.words {
+				var match float64
+
+				switch {
+				case word == term.word:
+					match = exactMatch
+				case strings.HasPrefix(word, term.word):
+					match = prefixMatch
+				case term.fuzzy[word]:
+					match = fuzzyMatch
+				}
+
+				if match*field.weight > best {
+					best = match * field.weight
+				}
+			}
+		}
+
+		if best == 0 {
+			return 0
+		}
+
+		score += best
+	}
+
+	return score
+}
+
+// searchWords splits text into lower case words of letters and digits, the way the index does.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
+
+// fuzzyMatches returns the words of the vocabulary within a few typos of a search word: one typo for
+// words of 4 to 7 letters, and two for longer words. Shorter words have to match exactly, or by prefix.
+func fuzzyMatches(word string, vocabulary []string) map[string]bool {
+	length := len([]rune(word))
+
+	maxEdits := 2
+	switch {
+	case length < 4:
+		return nil
+	case length < 8:
+		maxEdits = 1
+	}
+
+	matches := map[string]bool{}
+	for _, candidate := range vocabulary {
+		if strings.HasPrefix(candidate, word) {
+			continue
+		}
+
+		if diff := len([]rune(candidate)) - length; diff > maxEdits || -diff > maxEdits {
+			continue
+		}
+
+		if editDistance(word, candidate) <= maxEdits {
+			matches[candidate] = true
+		}
+	}
+
+	return matches
+}
+
+// editDistance is the Levenshtein distance between two words: the number of letters that have to be
+// inserted, deleted or substituted to turn one into the other.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev = curr
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+
+	if c < a {
+		a = c
+	}
+
+	return a
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRacesRepo_Search(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+		loadSearchFixtures(t, racingDB)
+
+		visible := true
+
+		tests := []struct {
+			name    string
+			in      *racing.SearchRacesRequest
+			wantIDs []int64
+		}{
+			{
+				name:    "exact",
+				in:      &racing.SearchRacesRequest{Q: "Zephyrine"},
+				wantIDs: []int64{201, 203},
+			},
+			{
+				name:    "prefix",
+				in:      &racing.SearchRacesRequest{Q: "zeph"},
+				wantIDs: []int64{201, 203},
+			},
+			{
+				name:    "fuzzy",
+				in:      &racing.SearchRacesRequest{Q: "zephyrene"},
+				wantIDs: []int64{201, 203},
+			},
+			{
+				name:    "name ranks over venue",
+				in:      &racing.SearchRacesRequest{Q: "quorndale"},
+				wantIDs: []int64{202, 201, 203},
+			},
+			{
+				name:    "runner with typo",
+				in:      &racing.SearchRacesRequest{Q: "xanthipe"},
+				wantIDs: []int64{202},
+			},
+			{
+				name:    "every term must match",
+				in:      &racing.SearchRacesRequest{Q: "Quorndale, Xanthippe!"},
+				wantIDs: []int64{202},
+			},
+			{
+				name:    "visible only",
+				in:      &racing.SearchRacesRequest{Q: "zephyrine", Visible: &visible},
+				wantIDs: []int64{201},
+			},
+			{
+				name:    "page size",
+				in:      &racing.SearchRacesRequest{Q: "quorndale", PageSize: 1},
+				wantIDs: []int64{202},
+			},
+			{
+				name: "no match",
+				in:   &racing.SearchRacesRequest{Q: "qwxzvb"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				races, err := racesRepo.Search(tt.in)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantIDs, raceIDs(races))
+			})
+		}
+
+		_, err := racesRepo.Search(&racing.SearchRacesRequest{Q: " !? "})
+		assert.ErrorIs(t, err, ErrInvalidSearch)
+
+		_, err = racesRepo.Search(&racing.SearchRacesRequest{Q: "zephyrine", PageSize: -1})
+		assert.ErrorIs(t, err, ErrInvalidPageSize)
+	})
+}
+
+func TestRacesRepo_SearchIndexUpdates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		racesRepo := createRepo(t, racingDB)
+		loadSearchFixtures(t, racingDB)
+
+		race, err := racesRepo.Get(201)
+		require.NoError(t, err)
+
+		race.Name = "Quillsworth Stakes"
+		race, err = racesRepo.Update(race, []string{"name"})
+		require.NoError(t, err)
+
+		races, err := racesRepo.Search(&racing.SearchRacesRequest{Q: "quillsworth"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{201}, raceIDs(races))
+
+		races, err = racesRepo.Search(&racing.SearchRacesRequest{Q: "zephyrine"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{203}, raceIDs(races))
+
+		require.NoError(t, racesRepo.Delete(202, 0))
+
+		races, err = racesRepo.Search(&racing.SearchRacesRequest{Q: "xanthippe"})
+		require.NoError(t, err)
+		assert.Empty(t, races)
+	})
+}
+
+func TestRacesRepo_SearchVocabularyCached(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		repo := createRepo(t, racingDB)
+		loadSearchFixtures(t, racingDB)
+
+		races, err := repo.Search(&racing.SearchRacesRequest{Q: "zephyrene"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{201, 203}, raceIDs(races))
+
+		race, err := repo.Get(201)
+		require.NoError(t, err)
+
+		race.Name = "Quillsworth Stakes"
+		_, err = repo.Update(race, []string{"name"})
+		require.NoError(t, err)
+
+		// New words match by prefix straight away...
+		races, err = repo.Search(&racing.SearchRacesRequest{Q: "quills"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{201}, raceIDs(races))
+
+		// ... and fuzzily once the vocabulary is read again.
+		races, err = repo.Search(&racing.SearchRacesRequest{Q: "quilsworth"})
+		require.NoError(t, err)
+		assert.Empty(t, races)
+
+		repo.(*racesRepo).vocabulary.read = time.Now().Add(-vocabularyTTL)
+
+		races, err = repo.Search(&racing.SearchRacesRequest{Q: "quilsworth"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{201}, raceIDs(races))
+	})
+}
+
+func TestRacesRepo_SearchDocuments(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, racingDB *sql.DB) {
+		repo := createRepo(t, racingDB).(*racesRepo)
+		loadSearchFixtures(t, racingDB)
+
+		// The index only yields races matching every term, by prefix or fuzzily.
+		documents, err := repo.searchDocuments([]searchTerm{
+			{word: "quorn"},
+			{word: "xanthipe", fuzzy: map[string]bool{"xanthippe": true}},
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, documents, 1)
+		assert.Equal(t, int64(202), documents[0].raceID)
+
+		// However many races match, only so many are ranked.
+		start := time.Now().Add(time.Hour).Truncate(time.Second)
+		fixtures := &Fixtures{}
+		for i := 1; i <= maxSearchCandidates+1; i++ {
+			fixtures.Races = append(fixtures.Races, &racing.Race{
+				Id: int64(1000 + i), MeetingId: 200, Name: "Quorndale Handicap", Number: int64(10 + i), Visible: true, AdvertisedStartTime: timestamppb.New(start),
+			})
+		}
+		require.NoError(t, LoadFixtures(racingDB, fixtures))
+
+		documents, err = repo.searchDocuments([]searchTerm{{word: "quorndale"}}, nil)
+		require.NoError(t, err)
+		assert.Len(t, documents, maxSearchCandidates)
+
+		races, err := repo.Search(&racing.SearchRacesRequest{Q: "quorndale cup"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{202}, raceIDs(races))
+	})
+}
+
+func TestFuzzyMatches(t *testing.T) {
+	vocabulary := []string{"cup", "cups", "flemington", "flemingtn", "randwick", "randwik", "stakes", "stake"}
+
+	assert.Empty(t, fuzzyMatches("cap", vocabulary), "Short words don't match fuzzily.")
+	assert.Equal(t, map[string]bool{"stake": true, "stakes": true}, fuzzyMatches("stakez", vocabulary))
+	assert.Equal(t, map[string]bool{"flemington": true, "flemingtn": true}, fuzzyMatches("flemmington", vocabulary))
+	assert.Equal(t, map[string]bool{"randwik": true}, fuzzyMatches("randwick", vocabulary), "Prefix matches aren't fuzzy.")
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("cup", "cup"))
+	assert.Equal(t, 1, editDistance("cup", "cap"))
+	assert.Equal(t, 1, editDistance("cup", "cups"))
+	assert.Equal(t, 2, editDistance("flemington", "flmingtn"))
+	assert.Equal(t, 3, editDistance("", "cup"))
+}
+
+// loadSearchFixtures loads races with words unlike any seeded ones, so searches for them are predictable.
+func loadSearchFixtures(t *testing.T, racingDB *sql.DB) {
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	require.NoError(t, LoadFixtures(racingDB, &Fixtures{
+		Meetings: []*racing.Meeting{
+			{Id: 200, Venue: "Quorndale", Country: "AUS", Date: start.Format("2006-01-02")},
+		},
+		Races: []*racing.Race{
+			{Id: 201, MeetingId: 200, Name: "Zephyrine Stakes", Number: 1, Visible: true, AdvertisedStartTime: timestamppb.New(start)},
+			{Id: 202, MeetingId: 200, Name: "Quorndale Cup", Number: 2, Visible: true, AdvertisedStartTime: timestamppb.New(start.Add(time.Hour))},
+			{Id: 203, MeetingId: 200, Name: "Zephyrine Plate", Number: 3, Visible: false, AdvertisedStartTime: timestamppb.New(start.Add(2 * time.Hour))},
+		},
+	}))
+
+	_, err := racingDB.Exec(
+		dialectOf(racingDB).rebind(`INSERT INTO runners(id, race_id, number, name, barrier, jockey, trainer, weight, scratched) VALUES (?,?,?,?,?,?,?,?,?)`),
+		20001, 202, 1, "Xanthippe Blaze", 1, "J. Smith", "T. Jones", 57.5, false,
+	)
+	require.NoError(t, err)
+}
+
+func raceIDs(races []*racing.Race) []int64 {
+	var ids []int64
+	for _, race := range races {
+		ids = append(ids, race.Id)
+	}
+
+	return ids
+}
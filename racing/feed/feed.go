@@ -0,0 +1,118 @@
+// Package feed ingests races from external racing data feeds.
+package feed
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// MessageType is the kind of change a feed message carries.
+type MessageType string
+
+const (
+	// RaceUpdated messages carry the full, latest details of a race, which is created if it's new.
+	RaceUpdated MessageType = "race_updated"
+	// RaceAbandoned messages withdraw a race, which is deleted.
+	RaceAbandoned MessageType = "race_abandoned"
+)
+
+// Message is a single message published by a feed.
+type Message struct {
+	// ID uniquely identifies the message within its feed.
+	ID string
+	// Type of change the message carries.
+	Type MessageType
+	// SourceID identifies the race the message is about in the feed.
+	SourceID string
+	// Sequence orders the messages about a race. Messages with a sequence at or below one that has
+	// already been applied to the race are stale or duplicated, and are skipped.
+	Sequence int64
+	// Race is the race's latest details, carried by RaceUpdated messages.
+	Race *racing.Race
+}
+
+// FeedProvider is a source of feed messages, which is polled for new messages.
+type FeedProvider interface {
+	// Poll will return the messages published after the cursor, along with the cursor to poll from
+	// next. An empty cursor polls from the start of the feed.
+	Poll(ctx context.Context, cursor string) ([]Message, string, error)
+}
+
+// Subscriber is implemented by feed providers that push messages as they are published, rather than
+// being polled.
+type Subscriber interface {
+	// Subscribe will hand each message published to the handler, until the context is done, the feed
+	// ends or the handler returns an error.
+	Subscribe(ctx context.Context, handle func(Message) error) error
+}
+
+// message is the JSON form of a Message, as recorded and served by the stub providers.
+type message struct {
+	ID       string          `json:"id"`
+	Type     MessageType     `json:"type"`
+	SourceID string          `json:"source_id"`
+	Sequence int64           `json:"sequence"`
+	Race     json.RawMessage `json:"race,omitempty"`
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	out := message{ID: m.ID, Type: m.Type, SourceID: m.SourceID, Sequence: m.Sequence}
+
+	if m.Race != nil {
+		race, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m.Race)
+		if err != nil {
+			return nil, err
+		}
+
+		out.Race = race
+	}
+
+	return json.Marshal(out)
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var in message
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*m = Message{ID: in.ID, Type: in.Type, SourceID: in.SourceID, Sequence: in.Sequence}
+
+	if len(in.Race) > 0 {
+		m.Race = &racing.Race{}
+		if err := protojson.Unmarshal(in.Race, m.Race); err != nil {
+			return fmt.Errorf("message %s: race: %w", in.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ReadMessages reads a recording of feed messages, one JSON message per line.
+func ReadMessages(r io.Reader) ([]Message, error) {
+	var messages []Message
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, scanner.Err()
+}
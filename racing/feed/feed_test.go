@@ -0,0 +1,39 @@
+package feed
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReadMessages(t *testing.T) {
+	messages := readRecording(t)
+	require.Len(t, messages, 8)
+
+	assert.Equal(t, "m1", messages[0].ID)
+	assert.Equal(t, RaceUpdated, messages[0].Type)
+	assert.Equal(t, "feed-1", messages[0].SourceID)
+	assert.Equal(t, int64(1), messages[0].Sequence)
+	assert.Equal(t, "Maiden Plate", messages[0].Race.GetName())
+
+	assert.Equal(t, RaceAbandoned, messages[6].Type)
+	assert.Nil(t, messages[6].Race)
+
+	// Messages survive a round trip through JSON, as served by the replay handler.
+	data, err := json.Marshal(messages[0])
+	require.NoError(t, err)
+
+	var msg Message
+	require.NoError(t, json.Unmarshal(data, &msg))
+	assert.Equal(t, messages[0].ID, msg.ID)
+	assert.True(t, proto.Equal(messages[0].Race, msg.Race))
+
+	_, err = ReadMessages(strings.NewReader(`{"id": "m1"}` + "\n" + `{"race": {"name": 1}}`))
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "line 2: "), err.Error())
+	}
+}
@@ -0,0 +1,73 @@
+package feed
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// FileProvider replays a recording of feed messages from a file, one JSON message per line. The file
+// is read again on every poll, so messages appended to it are picked up.
+type FileProvider struct {
+	path      string
+	batchSize int
+}
+
+// NewFileProvider creates a provider replaying the recording at path, batchSize messages per poll.
+func NewFileProvider(path string, batchSize int) *FileProvider {
+	return &FileProvider{path: path, batchSize: batchSize}
+}
+
+// Poll returns the next batch of recorded messages. The cursor is the number of messages already
+// returned.
+func (p *FileProvider) Poll(ctx context.Context, cursor string) ([]Message, string, error) {
+	offset, err := parseCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	messages, err := ReadMessages(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", p.path, err)
+	}
+
+	batch := page(messages, offset, p.batchSize)
+
+	return batch, strconv.Itoa(offset + len(batch)), nil
+}
+
+// parseCursor parses an offset cursor, where an empty cursor is the start of the feed.
+func parseCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	offset, err := strconv.Atoi(cursor)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+
+	return offset, nil
+}
+
+// page returns up to size messages from offset, or all the messages from offset if size isn't positive.
+func page(messages []Message, offset, size int) []Message {
+	if offset >= len(messages) {
+		return nil
+	}
+
+	messages = messages[offset:]
+	if size > 0 && len(messages) > size {
+		messages = messages[:size]
+	}
+
+	return messages
+}
@@ -0,0 +1,83 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// page of messages, as served by a feed's HTTP endpoint.
+type httpPage struct {
+	Messages []Message `json:"messages"`
+	Cursor   string    `json:"cursor"`
+}
+
+// HTTPProvider polls a feed's HTTP endpoint, which serves the messages after the cursor given as a
+// query parameter, e.g. GET /feed?cursor=42, as a JSON object with "messages" and "cursor" fields.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPProvider creates a provider polling the feed served at url.
+func NewHTTPProvider(url string, client *http.Client) *HTTPProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPProvider{url: url, client: client}
+}
+
+func (p *HTTPProvider) Poll(ctx context.Context, cursor string) ([]Message, string, error) {
+	u, err := url.Parse(p.url)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("polling %s: %s", p.url, res.Status)
+	}
+
+	var body httpPage
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, "", fmt.Errorf("polling %s: %w", p.url, err)
+	}
+
+	return body.Messages, body.Cursor, nil
+}
+
+// NewReplayHandler serves recorded messages the way HTTPProvider expects a feed to, pageSize messages
+// at a time. It stands in for a real feed when testing ingestion offline.
+func NewReplayHandler(messages []Message, pageSize int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, err := parseCursor(r.URL.Query().Get("cursor"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		batch := page(messages, offset, pageSize)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(httpPage{Messages: batch, Cursor: strconv.Itoa(offset + len(batch))})
+	})
+}
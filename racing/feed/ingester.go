@@ -0,0 +1,162 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"git.neds.sh/matty/entain/racing/service"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// Ingester applies the messages of a feed to the races repository.
+//
+// Feeds deliver messages at least once, and not necessarily in order. Each race's messages are ordered
+// by their sequence, and the sequence of the latest message applied to each race is recorded, so
+// duplicated and stale messages are skipped rather than rolling a race back.
+type Ingester struct {
+	provider     FeedProvider
+	racesRepo    db.RacesRepo
+	meetingsRepo db.MeetingsRepo
+	feedRepo     db.FeedRepo
+	interval     time.Duration
+}
+
+// NewIngester creates an ingester of a feed, polling it every interval unless it can be subscribed to.
+func NewIngester(provider FeedProvider, racesRepo db.RacesRepo, meetingsRepo db.MeetingsRepo, feedRepo db.FeedRepo, interval time.Duration) *Ingester {
+	return &Ingester{
+		provider:     provider,
+		racesRepo:    racesRepo,
+		meetingsRepo: meetingsRepo,
+		feedRepo:     feedRepo,
+		interval:     interval,
+	}
+}
+
+// Run ingests the feed until the context is done, or until the feed ends when subscribed to.
+func (i *Ingester) Run(ctx context.Context) error {
+	if subscriber, ok := i.provider.(Subscriber); ok {
+		err := subscriber.Subscribe(ctx, i.Handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+
+	var cursor string
+	for {
+		next, err := i.Poll(ctx, cursor)
+		if err != nil {
+			// The feed is polled again from the same cursor, so nothing is missed.
+			log.Printf("failed polling feed: %s\n", err)
+		} else {
+			cursor = next
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll applies the messages published after the cursor, returning the cursor to poll from next. If a
+// message can't be applied, the cursor stays put so the batch is retried.
+func (i *Ingester) Poll(ctx context.Context, cursor string) (string, error) {
+	messages, next, err := i.provider.Poll(ctx, cursor)
+	if err != nil {
+		return cursor, err
+	}
+
+	for _, msg := range messages {
+		if err := i.Handle(msg); err != nil {
+			return cursor, err
+		}
+	}
+
+	return next, nil
+}
+
+// Handle applies a single message. Messages that are malformed or rejected are logged and skipped, as
+// retrying them won't help, while failing to write to the repositories returns an error.
+func (i *Ingester) Handle(msg Message) error {
+	if msg.SourceID == "" || msg.Sequence <= 0 {
+		log.Printf("skipping feed message %s: source_id and a positive sequence must be given\n", msg.ID)
+		return nil
+	}
+
+	applied, err := i.feedRepo.Sequence(msg.SourceID)
+	if err != nil {
+		return err
+	}
+
+	if msg.Sequence <= applied {
+		// Duplicated, or overtaken by a later message.
+		return nil
+	}
+
+	switch msg.Type {
+	case RaceUpdated:
+		err = i.updateRace(msg)
+	case RaceAbandoned:
+		err = i.abandonRace(msg)
+	default:
+		log.Printf("skipping feed message %s: unknown type %q\n", msg.ID, msg.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("feed message %s: %w", msg.ID, err)
+	}
+
+	return i.feedRepo.SetSequence(msg.SourceID, msg.Sequence)
+}
+
+func (i *Ingester) updateRace(msg Message) error {
+	if msg.Race == nil {
+		log.Printf("skipping feed message %s: race must be given\n", msg.ID)
+		return nil
+	}
+
+	// Races are identified by their source ID, whatever the feed calls them.
+	race := proto.Clone(msg.Race).(*racing.Race)
+	race.Id = 0
+	race.SourceId = msg.SourceID
+
+	res, err := service.ImportRaces(i.racesRepo, i.meetingsRepo, []*racing.Race{race})
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range res.Errors {
+		log.Printf("skipping feed message %s: %s\n", msg.ID, rowErr.Message)
+	}
+
+	return nil
+}
+
+func (i *Ingester) abandonRace(msg Message) error {
+	race, err := i.racesRepo.GetBySourceID(msg.SourceID)
+	if errors.Is(err, db.ErrRaceNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = i.racesRepo.Delete(race.Id, 0)
+	if errors.Is(err, db.ErrRaceNotFound) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+const recording = "testdata/feed.ndjson"
+
+func TestIngester_FileProvider(t *testing.T) {
+	racingDB := createDB(t)
+	ingester := createIngester(racingDB, NewFileProvider(recording, 3))
+
+	var cursor string
+	for _, want := range []string{"3", "6", "8", "8"} {
+		var err error
+		cursor, err = ingester.Poll(context.Background(), cursor)
+		require.NoError(t, err)
+		assert.Equal(t, want, cursor)
+	}
+
+	assertIngested(t, racingDB)
+}
+
+func TestIngester_HTTPProvider(t *testing.T) {
+	server := httptest.NewServer(NewReplayHandler(readRecording(t), 2))
+	defer server.Close()
+
+	racingDB := createDB(t)
+	ingester := createIngester(racingDB, NewHTTPProvider(server.URL, server.Client()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go ingester.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		sequence, err := db.NewFeedRepo(racingDB).Sequence("feed-2")
+		return err == nil && sequence == 2
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+
+	assertIngested(t, racingDB)
+}
+
+func TestIngester_Subscriber(t *testing.T) {
+	racingDB := createDB(t)
+	ingester := createIngester(racingDB, replay(readRecording(t)))
+
+	require.NoError(t, ingester.Run(context.Background()))
+
+	assertIngested(t, racingDB)
+}
+
+func TestIngester_Redelivery(t *testing.T) {
+	racingDB := createDB(t)
+	ingester := createIngester(racingDB, replay(readRecording(t)))
+
+	// Replaying the whole feed again, as a provider might after restarting, changes nothing.
+	require.NoError(t, ingester.Run(context.Background()))
+	first, err := db.NewRacesRepo(racingDB).GetBySourceID("feed-1")
+	require.NoError(t, err)
+
+	require.NoError(t, ingester.Run(context.Background()))
+	second, err := db.NewRacesRepo(racingDB).GetBySourceID("feed-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Id, second.Id)
+	assert.Equal(t, first.Version, second.Version)
+}
+
+// assertIngested checks the races left behind by the recording: feed-1's latest update applied over the
+// stale one, feed-2 abandoned despite its first update being redelivered, and feed-3 rejected.
+func assertIngested(t *testing.T, racingDB *sql.DB) {
+	racesRepo := db.NewRacesRepo(racingDB)
+
+	race, err := racesRepo.GetBySourceID("feed-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Maiden Plate (Div 1)", race.Name)
+		assert.True(t, race.Visible)
+		assert.Equal(t, time.Date(2031, 1, 1, 1, 5, 0, 0, time.UTC), race.AdvertisedStartTime.AsTime())
+	}
+
+	_, err = racesRepo.GetBySourceID("feed-2")
+	assert.ErrorIs(t, err, db.ErrRaceNotFound)
+
+	_, err = racesRepo.GetBySourceID("feed-3")
+	assert.ErrorIs(t, err, db.ErrRaceNotFound)
+
+	races, _, err := racesRepo.List(&racing.ListRacesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, races, 101)
+}
+
+// replay is a feed provider that pushes recorded messages to its subscribers.
+type replay []Message
+
+func (r replay) Poll(ctx context.Context, cursor string) ([]Message, string, error) {
+	return nil, cursor, nil
+}
+
+func (r replay) Subscribe(ctx context.Context, handle func(Message) error) error {
+	for _, msg := range r {
+		if err := handle(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readRecording(t *testing.T) []Message {
+	file, err := os.Open(recording)
+	require.NoError(t, err)
+	defer file.Close()
+
+	messages, err := ReadMessages(file)
+	require.NoError(t, err)
+
+	return messages
+}
+
+func createIngester(racingDB *sql.DB, provider FeedProvider) *Ingester {
+	return NewIngester(provider, db.NewRacesRepo(racingDB), db.NewMeetingsRepo(racingDB), db.NewFeedRepo(racingDB), 10*time.Millisecond)
+}
+
+func createDB(t *testing.T) *sql.DB {
+	racingDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	// Keep the in-memory database alive across the pool's connections.
+	racingDB.SetMaxOpenConns(1)
+
+	migrator, err := db.NewMigrator(racingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	require.NoError(t, db.Seed(racingDB, db.SeedOptions{Seed: 1, Now: time.Now()}))
+
+	return racingDB
+}
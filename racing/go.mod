@@ -3,8 +3,13 @@ module git.neds.sh/matty/entain/racing
 go 1.16
 
 require (
+	github.com/MicahParks/keyfunc v1.9.0
+	github.com/alicebob/miniredis/v2 v2.14.3
+	github.com/go-redis/redis/v8 v8.4.2
+	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/golang/protobuf v1.4.3
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.3.0
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/stretchr/testify v1.8.1
 	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110
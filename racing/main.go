@@ -2,53 +2,303 @@ package main
 
 import (
 	"database/sql"
+	"errors"
+	"expvar"
 	"flag"
+	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/cache"
+	"git.neds.sh/matty/entain/racing/certs"
 	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/feed"
 	"git.neds.sh/matty/entain/racing/proto/racing"
 	"git.neds.sh/matty/entain/racing/service"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
 	grpcEndpoint = flag.String("grpc-endpoint", "localhost:9000", "gRPC server endpoint")
+	dbDSN        = flag.String("db-dsn", "./db/racing.db", "racing database: the path of a SQLite database, or a postgres:// URL")
+	seed         = flag.Bool("seed", false, "seed the database with dummy meetings, races and runners")
+	seedValue    = flag.Int64("seed-value", 1, "random seed of the dummy data, the same value always seeds the same data")
+	fixtures     = flag.String("fixtures", "", "comma separated list of JSON or CSV fixture files to load into the database")
+	feedSource   = flag.String("feed", "", "racing data feed to ingest: the path of a recorded feed, or an http(s) URL")
+	feedInterval = flag.Duration("feed-interval", 5*time.Second, "how often the racing data feed is polled")
+	cacheSize    = flag.Int("cache-size", 1000, "number of pages of races cached in process, or 0 to disable the cache")
+	cacheTTL     = flag.Duration("cache-ttl", 2*time.Second, "how long pages of races are cached for")
+	cacheRedis   = flag.String("cache-redis", "", "redis:// URL of a Redis server to cache pages of races in, shared between instances, instead of in process")
+	jwksPath     = flag.String("jwks", "", "JSON Web Key Set file of the keys trusted to sign bearer tokens; without one, every caller is anonymous and admin RPCs are refused")
+	metricsAddr  = flag.String("metrics-endpoint", "localhost:9100", "HTTP endpoint serving metrics at /debug/vars, or empty to disable")
+	tlsCert      = flag.String("tls-cert", "", "PEM certificate file to serve gRPC over TLS with, along with -tls-key")
+	tlsKey       = flag.String("tls-key", "", "PEM private key file of -tls-cert")
+	tlsClientCA  = flag.String("tls-client-ca", "", "PEM file of the CAs trusted to sign client certificates, which clients must then present")
+	tlsReload    = flag.Duration("tls-reload-interval", 10*time.Second, "how often the TLS certificate files are checked for changes")
 )
 
 func main() {
 	flag.Parse()
 
+	if flag.Arg(0) == "migrate" {
+		if err := migrate(flag.Args()[1:]); err != nil {
+			log.Fatalf("failed migrating database: %s\n", err)
+		}
+
+		return
+	}
+
+	if flag.Arg(0) == "import" {
+		if err := importRaces(flag.Args()[1:]); err != nil {
+			log.Fatalf("failed importing races: %s\n", err)
+		}
+
+		return
+	}
+
 	if err := run(); err != nil {
 		log.Fatalf("failed running grpc server: %s\n", err)
 	}
 }
 
+// migrate runs the migrate subcommand: "up" applies all pending migrations, "down [n]" reverts the
+// last n (default 1), and "status" lists the migrations and when they were applied.
+func migrate(args []string) error {
+	racingDB, err := db.Open(*dbDSN)
+	if err != nil {
+		return err
+	}
+	defer racingDB.Close()
+
+	migrator, err := db.NewMigrator(racingDB)
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			log.Printf("applied migration %d_%s\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations to revert %q", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			log.Printf("reverted migration %d_%s\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			applied := "pending"
+			if !status.AppliedAt.IsZero() {
+				applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %q, want up, down or status", command)
+}
+
+// importRaces runs the import subcommand, which creates or updates the races of CSV or JSON files by
+// their source ID. Each file is imported in a single transaction, and not at all if any race is invalid.
+func importRaces(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("no files to import given")
+	}
+
+	racingDB, err := db.Open(*dbDSN)
+	if err != nil {
+		return err
+	}
+	defer racingDB.Close()
+
+	migrator, err := db.NewMigrator(racingDB)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Check(); err != nil {
+		return err
+	}
+
+	// Imports drop the races cached by servers sharing the cache.
+	racesRepo, _, err := newRacesRepos(racingDB)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		races, err := db.ReadRaces(path)
+		if err != nil {
+			return err
+		}
+
+		res, err := service.ImportRaces(racesRepo, db.NewMeetingsRepo(racingDB), races)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, rowErr := range res.Errors {
+			log.Printf("%s: row %d (%s): %s\n", path, rowErr.Row, rowErr.SourceId, rowErr.Message)
+		}
+
+		if len(res.Errors) > 0 {
+			return fmt.Errorf("%s: %d invalid races, nothing was imported", path, len(res.Errors))
+		}
+
+		log.Printf("%s: %d created, %d updated, %d unchanged\n", path, res.Created, res.Updated, res.Unchanged)
+	}
+
+	return nil
+}
+
 func run() error {
 	conn, err := net.Listen("tcp", ":9000")
 	if err != nil {
 		return err
 	}
 
-	racingDB, err := sql.Open("sqlite3", "./db/racing.db")
+	racingDB, err := db.Open(*dbDSN)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to serve from a schema the repositories don't expect.
+	migrator, err := db.NewMigrator(racingDB)
 	if err != nil {
 		return err
 	}
 
-	racesRepo := db.NewRacesRepo(racingDB)
-	if err := racesRepo.Init(); err != nil {
+	if err := migrator.Check(); err != nil {
+		if errors.Is(err, db.ErrSchemaBehind) {
+			return fmt.Errorf("%w, run `racing migrate up` first", err)
+		}
+
+		return err
+	}
+
+	// For test/example purposes, the DB can be seeded with dummy data, and loaded with known data.
+	if *seed {
+		if err := db.Seed(racingDB, db.SeedOptions{Seed: *seedValue, Now: time.Now()}); err != nil {
+			return err
+		}
+	}
+
+	if *fixtures != "" {
+		for _, path := range strings.Split(*fixtures, ",") {
+			loaded, err := db.ReadFixtures(path)
+			if err != nil {
+				return err
+			}
+
+			if err := db.LoadFixtures(racingDB, loaded); err != nil {
+				return fmt.Errorf("loading %s: %w", path, err)
+			}
+		}
+	}
+
+	racesRepo, resultsRepo, err := newRacesRepos(racingDB)
+	if err != nil {
 		return err
 	}
 
-	grpcServer := grpc.NewServer()
+	if *feedSource != "" {
+		ingester := feed.NewIngester(
+			newFeedProvider(*feedSource),
+			racesRepo,
+			db.NewMeetingsRepo(racingDB),
+			db.NewFeedRepo(racingDB),
+			*feedInterval,
+		)
+
+		go func() {
+			if err := ingester.Run(context.Background()); err != nil {
+				log.Printf("failed ingesting feed: %s\n", err)
+			}
+		}()
+	}
+
+	var verifier *auth.Verifier
+	if *jwksPath != "" {
+		if verifier, err = auth.LoadVerifier(*jwksPath); err != nil {
+			return err
+		}
+	} else {
+		log.Printf("no -jwks given, admin RPCs are refused\n")
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(auth.UnaryServerInterceptor(verifier, service.AdminPolicy)),
+		grpc.StreamInterceptor(auth.StreamServerInterceptor(verifier, service.AdminPolicy)),
+	}
+
+	switch {
+	case *tlsCert != "":
+		reloader, err := certs.NewReloader(*tlsCert, *tlsKey, *tlsClientCA, *tlsReload)
+		if err != nil {
+			return err
+		}
+
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+	case *tlsClientCA != "":
+		return errors.New("-tls-client-ca requires -tls-cert and -tls-key")
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
 
 	racing.RegisterRacingServer(
 		grpcServer,
 		service.NewRacingService(
 			racesRepo,
+			db.NewMeetingsRepo(racingDB),
+			db.NewRunnersRepo(racingDB),
+			resultsRepo,
+			db.NewPricesRepo(racingDB),
 		),
 	)
 
+	if *metricsAddr != "" {
+		go func() {
+			log.Printf("metrics listening on: http://%s/debug/vars\n", *metricsAddr)
+
+			if err := http.ListenAndServe(*metricsAddr, nil); err != nil {
+				log.Printf("failed serving metrics: %s\n", err)
+			}
+		}()
+	}
+
 	log.Printf("gRPC server listening on: %s\n", *grpcEndpoint)
 
 	if err := grpcServer.Serve(conn); err != nil {
@@ -57,3 +307,39 @@ func run() error {
 
 	return nil
 }
+
+// newRacesRepos returns the races and results repositories, reading pages of races through a cache
+// unless it's disabled. Writes through either repository drop the cached pages.
+func newRacesRepos(racingDB *sql.DB) (db.RacesRepo, db.ResultsRepo, error) {
+	racesRepo, resultsRepo := db.NewRacesRepo(racingDB), db.NewResultsRepo(racingDB)
+
+	var store cache.Store
+
+	switch {
+	case *cacheRedis != "":
+		options, err := redis.ParseURL(*cacheRedis)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid -cache-redis: %w", err)
+		}
+
+		store = cache.NewRedisStore(redis.NewClient(options), "racing:races")
+	case *cacheSize > 0:
+		store = cache.NewLRUStore(*cacheSize)
+	default:
+		return racesRepo, resultsRepo, nil
+	}
+
+	raceCache := db.NewRaceCache(store, *cacheTTL, expvar.NewMap("races_cache"))
+
+	return raceCache.Races(racesRepo), raceCache.Results(resultsRepo), nil
+}
+
+// newFeedProvider returns the provider of the feed at source, either an HTTP(S) URL or the path of a
+// recorded feed.
+func newFeedProvider(source string) feed.FeedProvider {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return feed.NewHTTPProvider(source, http.DefaultClient)
+	}
+
+	return feed.NewFileProvider(source, 100)
+}
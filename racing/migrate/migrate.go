@@ -0,0 +1,340 @@
+// Package migrate applies the versioned schema migrations embedded in a service to its database, recording
+// the applied versions in the database's schema_migrations table.
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SQLite is the dialect of SQLite databases.
+	SQLite Dialect = "sqlite"
+	// Postgres is the dialect of PostgreSQL databases.
+	Postgres Dialect = "postgres"
+)
+
+var (
+	// ErrSchemaBehind is returned when a database has migrations that haven't been applied.
+	ErrSchemaBehind = errors.New("database schema is behind")
+	// ErrSchemaAhead is returned when a database has migrations applied that this build doesn't know.
+	ErrSchemaAhead = errors.New("database schema is ahead")
+)
+
+// migrationFileName matches migration files, e.g. "0001_create_races.up.sql".
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// addColumnIfNotExists matches the lines of SQLite scripts adding a column unless the table has it, which
+// SQLite doesn't support itself, e.g. "ALTER TABLE races ADD COLUMN IF NOT EXISTS version INTEGER;".
+var addColumnIfNotExists = regexp.MustCompile(`(?im)^ALTER TABLE (\w+) ADD COLUMN IF NOT EXISTS (\w+)(.*)$`)
+
+// Dialect is the SQL dialect of a database, which its migrations are written in.
+type Dialect string
+
+// Migration is a single, versioned change to the database schema.
+type Migration struct {
+	Version int64
+	Name    string
+
+	up   string
+	down string
+}
+
+// Status is a migration, along with when it was applied to the database.
+type Status struct {
+	Migration
+	// AppliedAt is the time the migration was applied, and zero if it is pending.
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New creates a new migrator applying the migrations in a directory of a file system, usually embedded,
+// to a database of the given dialect. Migrations are named "<version>_<name>.up.sql" and
+// "<version>_<name>.down.sql".
+func New(db *sql.DB, fsys fs.FS, dir string, dialect Dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest known migration.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version of the newest migration applied to the database, or zero if there
+// are none.
+func (m *Migrator) Version() (int64, error) {
+	if err := m.init(); err != nil {
+		return 0, err
+	}
+
+	var version int64
+	if err := m.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Check returns an error unless all known migrations, and no others, have been applied.
+func (m *Migrator) Check() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	switch latest := m.Latest(); {
+	case version < latest:
+		return fmt.Errorf("%w: at version %d, want %d", ErrSchemaBehind, version, latest)
+	case version > latest:
+		return fmt.Errorf("%w: at version %d, want %d", ErrSchemaAhead, version, latest)
+	}
+
+	return nil
+}
+
+// Status returns every known migration, along with when it was applied.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{Migration: migration, AppliedAt: applied[migration.Version]})
+	}
+
+	return statuses, nil
+}
+
+// Up applies all pending migrations in order, returning those that were applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.apply(migration.up, `INSERT INTO schema_migrations(version, name, applied_at) VALUES (?,?,?)`,
+			migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339))
+		if err != nil {
+			return done, fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the given number of most recently applied migrations, returning those that were
+// reverted.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := m.apply(migration.down, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// apply runs a migration script, and the statement recording it, in a single transaction.
+func (m *Migrator) apply(script, record string, args ...interface{}) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.dialect == SQLite {
+		if script, err = addMissingColumns(tx, script); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(m.dialect.rebind(record), args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addMissingColumns rewrites the lines of a SQLite script adding a column unless the table has it, into
+// plain ADD COLUMN statements if the table doesn't, and drops them if it does.
+func addMissingColumns(tx *sql.Tx, script string) (string, error) {
+	var err error
+
+	script = addColumnIfNotExists.ReplaceAllStringFunc(script, func(line string) string {
+		match := addColumnIfNotExists.FindStringSubmatch(line)
+		table, column := match[1], match[2]
+
+		var exists bool
+		if scanErr := tx.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&exists); scanErr != nil {
+			err = scanErr
+			return line
+		}
+
+		if exists {
+			return ""
+		}
+
+		return "ALTER TABLE " + table + " ADD COLUMN " + column + match[3]
+	})
+
+	return script, err
+}
+
+// applied returns the time each applied migration was applied, keyed by version.
+func (m *Migrator) applied() (map[int64]time.Time, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) init() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, name TEXT, applied_at TIMESTAMP)`)
+
+	return err
+}
+
+// loadMigrations reads the migrations in a directory of a file system, ordered by version. Every
+// migration must have both an up and a down script.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	files, err := fs.Glob(fsys, dir+"/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		name := path.Base(file)
+
+		match := migrationFileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q: %w", name, err)
+		}
+
+		script, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.up = string(script)
+		} else {
+			migration.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down scripts", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// rebind rewrites the "?" placeholders of a statement into the dialect's placeholders.
+func (d Dialect) rebind(statement string) string {
+	if d != Postgres {
+		return statement
+	}
+
+	for n := 1; strings.Contains(statement, "?"); n++ {
+		statement = strings.Replace(statement, "?", "$"+strconv.Itoa(n), 1)
+	}
+
+	return statement
+}
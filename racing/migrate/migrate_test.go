@@ -0,0 +1,129 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator_UpDown(t *testing.T) {
+	db := openDB(t)
+
+	migrator, err := New(db, fstest.MapFS{
+		"migrations/0001_create_things.up.sql":       {Data: []byte(`CREATE TABLE things (id INTEGER PRIMARY KEY);`)},
+		"migrations/0001_create_things.down.sql":     {Data: []byte(`DROP TABLE things;`)},
+		"migrations/0002_create_stuff.up.sql":        {Data: []byte(`CREATE TABLE stuff (id INTEGER PRIMARY KEY, name TEXT);`)},
+		"migrations/0002_create_stuff.down.sql":      {Data: []byte(`DROP TABLE stuff;`)},
+		"migrations/0010_index_stuff_names.up.sql":   {Data: []byte(`CREATE INDEX stuff_names ON stuff (name);`)},
+		"migrations/0010_index_stuff_names.down.sql": {Data: []byte(`DROP INDEX stuff_names;`)},
+	}, "migrations", SQLite)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), migrator.Latest())
+	assert.ErrorIs(t, migrator.Check(), ErrSchemaBehind)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 10}, versions(applied))
+	assert.NoError(t, migrator.Check())
+
+	reverted, err := migrator.Down(2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 2}, versions(reverted))
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.False(t, statuses[0].AppliedAt.IsZero())
+	assert.True(t, statuses[1].AppliedAt.IsZero())
+
+	// Migrations this build doesn't know are left alone, but the schema can't be trusted.
+	_, err = db.Exec(`INSERT INTO schema_migrations(version, name, applied_at) VALUES (11, 'unknown', '2021-03-03T01:30:57Z')`)
+	require.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(), ErrSchemaAhead)
+}
+
+func TestMigrator_AddColumnIfNotExists(t *testing.T) {
+	db := openDB(t)
+
+	_, err := db.Exec(`CREATE TABLE things (id INTEGER PRIMARY KEY, name TEXT)`)
+	require.NoError(t, err)
+
+	migrator, err := New(db, fstest.MapFS{
+		"migrations/0001_name_things.up.sql":   {Data: []byte("ALTER TABLE things ADD COLUMN IF NOT EXISTS name TEXT;\nALTER TABLE things ADD COLUMN IF NOT EXISTS size INTEGER NOT NULL DEFAULT 1;")},
+		"migrations/0001_name_things.down.sql": {Data: []byte(`SELECT 1;`)},
+	}, "migrations", SQLite)
+	require.NoError(t, err)
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	var columns int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('things')`).Scan(&columns))
+	assert.Equal(t, 3, columns)
+}
+
+func TestNew_InvalidMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "none",
+			fsys: fstest.MapFS{},
+		},
+		{
+			name: "invalid name",
+			fsys: fstest.MapFS{"migrations/create_things.up.sql": {}},
+		},
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{"migrations/0001_create_things.up.sql": {Data: []byte(`CREATE TABLE things (id INTEGER);`)}},
+		},
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"migrations/0001_create_things.up.sql":  {Data: []byte(`CREATE TABLE things (id INTEGER);`)},
+				"migrations/0001_create_stuff.down.sql": {Data: []byte(`DROP TABLE things;`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(openDB(t), tt.fsys, "migrations", SQLite)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDialect_Rebind(t *testing.T) {
+	statement := `INSERT INTO schema_migrations(version, name, applied_at) VALUES (?,?,?)`
+
+	assert.Equal(t, statement, SQLite.rebind(statement))
+	assert.Equal(t, `INSERT INTO schema_migrations(version, name, applied_at) VALUES ($1,$2,$3)`, Postgres.rebind(statement))
+}
+
+// openDB opens an empty in-memory database.
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// Keep the in-memory database alive across the pool's connections.
+	db.SetMaxOpenConns(1)
+
+	return db
+}
+
+func versions(migrations []Migration) []int64 {
+	var versions []int64
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+
+	return versions
+}
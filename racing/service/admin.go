@@ -0,0 +1,194 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// maxRaceNameLength caps the length of race names.
+const maxRaceNameLength = 200
+
+// AdminPolicy restricts the RPCs writing races, their results and their prices to the roles managing them.
+var AdminPolicy = auth.Policy{
+	"/racing.Racing/CreateRace":   {"admin"},
+	"/racing.Racing/UpdateRace":   {"admin"},
+	"/racing.Racing/DeleteRace":   {"admin"},
+	"/racing.Racing/ImportRaces":  {"admin"},
+	"/racing.Racing/ResultRace":   {"admin"},
+	"/racing.Racing/UpdatePrices": {"trader", "admin"},
+}
+
+func (s *racingService) CreateRace(ctx context.Context, in *racing.CreateRaceRequest) (*racing.Race, error) {
+	if in.Race == nil {
+		return nil, status.Error(codes.InvalidArgument, "race must be given")
+	}
+
+	if err := s.validateRace(in.Race); err != nil {
+		return nil, err
+	}
+
+	return s.racesRepo.Create(in.Race)
+}
+
+func (s *racingService) UpdateRace(ctx context.Context, in *racing.UpdateRaceRequest) (*racing.Race, error) {
+	if in.Race == nil {
+		return nil, status.Error(codes.InvalidArgument, "race must be given")
+	}
+
+	if in.Race.Version == 0 {
+		return nil, status.Error(codes.InvalidArgument, "version of the race being updated must be given")
+	}
+
+	fields, err := updateFields(in.GetUpdateMask().GetPaths())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	current, err := s.racesRepo.Get(in.Race.Id)
+	if err != nil {
+		return nil, raceWriteError(err, in.Race.Id)
+	}
+
+	// Validate the race as it will be once the update is applied.
+	updated := proto.Clone(current).(*racing.Race)
+	for _, field := range fields {
+		mergeRaceField(updated, in.Race, field)
+	}
+
+	if err := s.validateRace(updated); err != nil {
+		return nil, err
+	}
+
+	updated.Version = in.Race.Version
+
+	race, err := s.racesRepo.Update(updated, fields)
+	if err != nil {
+		return nil, raceWriteError(err, in.Race.Id)
+	}
+
+	return race, nil
+}
+
+func (s *racingService) DeleteRace(ctx context.Context, in *racing.DeleteRaceRequest) (*emptypb.Empty, error) {
+	if err := s.racesRepo.Delete(in.Id, in.Version); err != nil {
+		return nil, raceWriteError(err, in.Id)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// validateRace checks a race is fit to be written.
+func (s *racingService) validateRace(race *racing.Race) error {
+	problems, err := raceProblems(s.meetingsRepo, race)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return status.Error(codes.InvalidArgument, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// raceProblems describes everything that stops a race from being written.
+func raceProblems(meetingsRepo db.MeetingsRepo, race *racing.Race) ([]string, error) {
+	var problems []string
+
+	if name := strings.TrimSpace(race.Name); name == "" {
+		problems = append(problems, "name must not be empty")
+	} else if len(name) > maxRaceNameLength {
+		problems = append(problems, fmt.Sprintf("name must not be longer than %d characters", maxRaceNameLength))
+	}
+
+	if race.Number < 1 {
+		problems = append(problems, "number must be positive")
+	}
+
+	if race.AdvertisedStartTime == nil {
+		problems = append(problems, "advertised_start_time must be given")
+	} else if err := race.AdvertisedStartTime.CheckValid(); err != nil {
+		problems = append(problems, fmt.Sprintf("advertised_start_time is invalid: %s", err))
+	}
+
+	if _, err := meetingsRepo.Get(race.MeetingId); err != nil {
+		if !errors.Is(err, db.ErrMeetingNotFound) {
+			return nil, err
+		}
+
+		problems = append(problems, fmt.Sprintf("meeting %d does not exist", race.MeetingId))
+	}
+
+	return problems, nil
+}
+
+// updateFields resolves an update mask to the race fields it updates. An empty mask updates every
+// updatable field. The ID and version identify the race being updated, so are ignored.
+func updateFields(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return db.RaceUpdatableFields(), nil
+	}
+
+	updatable := map[string]bool{}
+	for _, field := range db.RaceUpdatableFields() {
+		updatable[field] = true
+	}
+
+	var fields []string
+
+	for _, path := range paths {
+		switch {
+		case path == "id" || path == "version":
+		case updatable[path]:
+			fields = append(fields, path)
+		default:
+			return nil, fmt.Errorf("field %q can't be updated", path)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.New("update_mask must name at least one updatable field")
+	}
+
+	return fields, nil
+}
+
+// mergeRaceField copies a single updatable field from one race to another.
+func mergeRaceField(dst, src *racing.Race, field string) {
+	switch field {
+	case "meeting_id":
+		dst.MeetingId = src.MeetingId
+	case "name":
+		dst.Name = src.Name
+	case "number":
+		dst.Number = src.Number
+	case "visible":
+		dst.Visible = src.Visible
+	case "advertised_start_time":
+		dst.AdvertisedStartTime = src.AdvertisedStartTime
+	}
+}
+
+// raceWriteError maps the repository's write errors onto gRPC statuses.
+func raceWriteError(err error, id int64) error {
+	switch {
+	case errors.Is(err, db.ErrRaceNotFound):
+		return status.Errorf(codes.NotFound, "race %d not found", id)
+	case errors.Is(err, db.ErrVersionConflict):
+		return status.Errorf(codes.Aborted, "race %d has been changed since it was read, fetch it again and retry", id)
+	case errors.Is(err, db.ErrInvalidUpdateMask):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return err
+}
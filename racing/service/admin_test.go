@@ -0,0 +1,141 @@
+package service
+
+import (
+	"net"
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/auth/authtest"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// writeMethods are the RPCs writing races, their results and their prices, which AdminPolicy must
+// restrict. Every other RPC only reads.
+var writeMethods = map[string]bool{
+	"CreateRace":   true,
+	"UpdateRace":   true,
+	"DeleteRace":   true,
+	"ImportRaces":  true,
+	"ResultRace":   true,
+	"UpdatePrices": true,
+}
+
+// readMethods are the RPCs anyone can call.
+var readMethods = map[string]bool{
+	"ListRaces":      true,
+	"GetRace":        true,
+	"SearchRaces":    true,
+	"ListMeetings":   true,
+	"GetMeeting":     true,
+	"ListRunners":    true,
+	"GetRaceResults": true,
+	"ListPrices":     true,
+	"WatchRaces":     true,
+}
+
+func TestAdminPolicy_Methods(t *testing.T) {
+	var methods []string
+	for _, method := range racing.Racing_ServiceDesc.Methods {
+		methods = append(methods, method.MethodName)
+	}
+	for _, stream := range racing.Racing_ServiceDesc.Streams {
+		methods = append(methods, stream.StreamName)
+	}
+
+	fullMethods := map[string]bool{}
+	for _, method := range methods {
+		assert.True(t, writeMethods[method] || readMethods[method], "%s must be classed as a write or a read.", method)
+		assert.Equal(t, writeMethods[method], AdminPolicy[fullMethod(method)] != nil, "Only writes, and every write, must be restricted: %s.", method)
+
+		fullMethods[fullMethod(method)] = true
+	}
+
+	for method := range AdminPolicy {
+		assert.True(t, fullMethods[method], "%s is not a racing method.", method)
+	}
+}
+
+func TestAdminPolicy_Enforced(t *testing.T) {
+	issuer := authtest.NewIssuer(t)
+	conn := dialRacing(t, issuer.Verifier(t))
+
+	tests := []struct {
+		name  string
+		token string
+		want  codes.Code
+	}{
+		{name: "anonymous", want: codes.Unauthenticated},
+		{name: "retail", token: issuer.Token(t, "bob", "retail"), want: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tt.token)
+			}
+
+			// Requests are left empty, as callers are refused before they're looked at.
+			for _, method := range racing.Racing_ServiceDesc.Methods {
+				if !writeMethods[method.MethodName] {
+					continue
+				}
+
+				err := conn.Invoke(ctx, fullMethod(method.MethodName), &emptypb.Empty{}, &emptypb.Empty{})
+				assert.Equal(t, tt.want, status.Code(err), method.MethodName)
+			}
+
+			for i, desc := range racing.Racing_ServiceDesc.Streams {
+				if !writeMethods[desc.StreamName] {
+					continue
+				}
+
+				stream, err := conn.NewStream(ctx, &racing.Racing_ServiceDesc.Streams[i], fullMethod(desc.StreamName))
+				require.NoError(t, err)
+				require.NoError(t, stream.CloseSend())
+
+				err = stream.RecvMsg(&emptypb.Empty{})
+				assert.Equal(t, tt.want, status.Code(err), desc.StreamName)
+			}
+		})
+	}
+}
+
+// dialRacing serves the racing service behind the auth interceptors enforcing AdminPolicy, returning a
+// connection to it.
+func dialRacing(t *testing.T, verifier *auth.Verifier) *grpc.ClientConn {
+	ln := bufconn.Listen(1 << 20)
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.UnaryServerInterceptor(verifier, AdminPolicy)),
+		grpc.StreamInterceptor(auth.StreamServerInterceptor(verifier, AdminPolicy)),
+	)
+	racing.RegisterRacingServer(server, createService(t))
+
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(
+		"bufconn",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.Dial() }),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// fullMethod returns the full name of a racing method, e.g. "/racing.Racing/CreateRace".
+func fullMethod(method string) string {
+	return "/" + racing.Racing_ServiceDesc.ServiceName + "/" + method
+}
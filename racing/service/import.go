@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+)
+
+// maxSourceIDLength caps the length of imported races' source IDs.
+const maxSourceIDLength = 100
+
+func (s *racingService) ImportRaces(stream racing.Racing_ImportRacesServer) error {
+	var races []*racing.Race
+
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		races = append(races, in.Race)
+	}
+
+	res, err := ImportRaces(s.racesRepo, s.meetingsRepo, races)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(res)
+}
+
+// ImportRaces validates a batch of races and, provided they are all valid, creates or updates them by
+// their source ID. It backs both the ImportRaces RPC and the import command.
+func ImportRaces(racesRepo db.RacesRepo, meetingsRepo db.MeetingsRepo, races []*racing.Race) (*racing.ImportRacesResponse, error) {
+	res := &racing.ImportRacesResponse{}
+
+	rows := map[string]int{}
+	for i, race := range races {
+		row := i + 1
+
+		if race == nil {
+			res.Errors = append(res.Errors, &racing.ImportRaceError{Row: int32(row), Message: "race must be given"})
+			continue
+		}
+
+		var problems []string
+
+		switch first, repeated := rows[race.SourceId]; {
+		case race.SourceId == "":
+			problems = append(problems, "source_id must be given")
+		case len(race.SourceId) > maxSourceIDLength:
+			problems = append(problems, fmt.Sprintf("source_id must not be longer than %d characters", maxSourceIDLength))
+		case repeated:
+			problems = append(problems, fmt.Sprintf("source_id is already imported by row %d", first))
+		default:
+			rows[race.SourceId] = row
+		}
+
+		invalid, err := raceProblems(meetingsRepo, race)
+		if err != nil {
+			return nil, err
+		}
+
+		if problems = append(problems, invalid...); len(problems) > 0 {
+			res.Errors = append(res.Errors, &racing.ImportRaceError{
+				Row:      int32(row),
+				SourceId: race.SourceId,
+				Message:  strings.Join(problems, "; "),
+			})
+		}
+	}
+
+	if len(res.Errors) > 0 {
+		return res, nil
+	}
+
+	outcomes, err := racesRepo.Import(races)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, outcome := range outcomes {
+		switch outcome {
+		case db.ImportCreated:
+			res.Created++
+		case db.ImportUpdated:
+			res.Updated++
+		case db.ImportUnchanged:
+			res.Unchanged++
+		}
+	}
+
+	return res, nil
+}
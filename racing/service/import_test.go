@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestImportRaces(t *testing.T) {
+	racingDB := createDB(t)
+	racesRepo, meetingsRepo := db.NewRacesRepo(racingDB), db.NewMeetingsRepo(racingDB)
+
+	start := timestamppb.New(time.Now().Add(time.Hour))
+	race := func(sourceID string, meetingID int64) *racing.Race {
+		return &racing.Race{SourceId: sourceID, MeetingId: meetingID, Name: "Imported", Number: 1, AdvertisedStartTime: start}
+	}
+
+	res, err := ImportRaces(racesRepo, meetingsRepo, []*racing.Race{
+		race("a", 1),
+		race("", 1),
+		race("a", 2),
+		race("b", 1000),
+		nil,
+	})
+	require.NoError(t, err)
+	assert.Zero(t, res.Created)
+
+	if assert.Len(t, res.Errors, 4) {
+		assert.Equal(t, int32(2), res.Errors[0].Row)
+		assert.Contains(t, res.Errors[0].Message, "source_id must be given")
+		assert.Contains(t, res.Errors[1].Message, "already imported by row 1")
+		assert.Equal(t, "b", res.Errors[2].SourceId)
+		assert.Contains(t, res.Errors[2].Message, "meeting 1000 does not exist")
+		assert.Equal(t, int32(5), res.Errors[3].Row)
+	}
+
+	// Nothing is imported when any race is invalid.
+	races, _, err := racesRepo.List(&racing.ListRacesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, races, 100)
+
+	res, err = ImportRaces(racesRepo, meetingsRepo, []*racing.Race{race("a", 1), race("b", 2)})
+	require.NoError(t, err)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, int32(2), res.Created)
+
+	res, err = ImportRaces(racesRepo, meetingsRepo, []*racing.Race{race("a", 1), race("b", 3)})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), res.Unchanged)
+	assert.Equal(t, int32(1), res.Updated)
+}
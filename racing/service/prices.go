@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *racingService) ListPrices(ctx context.Context, in *racing.ListPricesRequest) (*racing.ListPricesResponse, error) {
+	if _, err := s.getVisibleRace(ctx, in.RaceId); err != nil {
+		return nil, err
+	}
+
+	prices, err := s.pricesRepo.List(in.RaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &racing.ListPricesResponse{Prices: prices}, nil
+}
+
+func (s *racingService) UpdatePrices(ctx context.Context, in *racing.UpdatePricesRequest) (*racing.UpdatePricesResponse, error) {
+	race, err := s.racesRepo.Get(in.RaceId)
+	if err != nil {
+		if errors.Is(err, db.ErrRaceNotFound) {
+			return nil, status.Errorf(codes.NotFound, "race %d not found", in.RaceId)
+		}
+
+		return nil, err
+	}
+
+	// Betting closes with the race, and so does its market.
+	if race.Status != racing.Race_OPEN {
+		return nil, status.Errorf(codes.FailedPrecondition, "race %d is no longer open", in.RaceId)
+	}
+
+	runners, err := s.runnersRepo.List([]int64{in.RaceId})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePrices(in, runners); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	prices := make([]*racing.Price, 0, len(in.Prices))
+	for _, price := range in.Prices {
+		prices = append(prices, &racing.Price{RunnerId: price.RunnerId, RaceId: in.RaceId, Win: price.Win, Place: price.Place})
+	}
+
+	if _, err := s.pricesRepo.Update(prices); err != nil {
+		return nil, err
+	}
+
+	current, err := s.pricesRepo.Current([]int64{in.RaceId})
+	if err != nil {
+		return nil, err
+	}
+
+	return &racing.UpdatePricesResponse{Prices: current}, nil
+}
+
+// validatePrices checks new prices are for the race's unscratched runners, each priced once, and that
+// they are valid decimal odds: greater than 1, with any place price no greater than the win price.
+func validatePrices(in *racing.UpdatePricesRequest, runners []*racing.Runner) error {
+	if len(in.Prices) == 0 {
+		return errors.New("at least one price must be given")
+	}
+
+	byID := make(map[int64]*racing.Runner, len(runners))
+	for _, runner := range runners {
+		byID[runner.Id] = runner
+	}
+
+	priced := map[int64]bool{}
+
+	for _, price := range in.Prices {
+		runner, ok := byID[price.RunnerId]
+		if !ok {
+			return fmt.Errorf("runner %d is not running in race %d", price.RunnerId, in.RaceId)
+		}
+
+		if runner.Scratched {
+			return fmt.Errorf("runner %d is scratched", price.RunnerId)
+		}
+
+		if priced[price.RunnerId] {
+			return fmt.Errorf("runner %d is priced more than once", price.RunnerId)
+		}
+		priced[price.RunnerId] = true
+
+		if price.Win <= 1 {
+			return fmt.Errorf("win price of runner %d must be greater than 1", price.RunnerId)
+		}
+
+		if price.Place != 0 && (price.Place <= 1 || price.Place > price.Win) {
+			return fmt.Errorf("place price of runner %d must be greater than 1 and no greater than its win price", price.RunnerId)
+		}
+	}
+
+	return nil
+}
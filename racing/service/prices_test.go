@@ -0,0 +1,113 @@
+package service
+
+import (
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRacingService_UpdatePrices(t *testing.T) {
+	s := createService(t)
+
+	open := racing.Race_OPEN
+	races, err := s.ListRaces(context.Background(), &racing.ListRacesRequest{
+		Filter:         &racing.ListRacesRequestFilter{Status: &open},
+		IncludeRunners: true,
+		PageSize:       1,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, races.Races)
+
+	race := races.Races[0]
+
+	var runner *racing.Runner
+	for _, r := range race.Runners {
+		if !r.Scratched {
+			runner = r
+			break
+		}
+	}
+	require.NotNil(t, runner)
+	require.NotNil(t, runner.Price, "seeded runners are priced")
+
+	res, err := s.UpdatePrices(context.Background(), &racing.UpdatePricesRequest{
+		RaceId: race.Id,
+		Prices: []*racing.Price{{RunnerId: runner.Id, Win: 3.2, Place: 1.5}},
+	})
+	require.NoError(t, err)
+
+	for _, price := range res.Prices {
+		if price.RunnerId == runner.Id {
+			assert.Equal(t, 3.2, price.Win)
+		}
+	}
+
+	// The new price is the runner's current price...
+	got, err := s.GetRace(context.Background(), &racing.GetRaceRequest{Id: race.Id, IncludeRunners: true})
+	require.NoError(t, err)
+
+	for _, r := range got.Runners {
+		if r.Id == runner.Id {
+			assert.Equal(t, 3.2, r.Price.GetWin())
+			assert.Equal(t, 1.5, r.Price.GetPlace())
+		}
+	}
+
+	// ... and the opening price is kept as history.
+	history, err := s.ListPrices(context.Background(), &racing.ListPricesRequest{RaceId: race.Id})
+	require.NoError(t, err)
+
+	var moves []float64
+	for _, price := range history.Prices {
+		if price.RunnerId == runner.Id {
+			moves = append(moves, price.Win)
+		}
+	}
+	assert.Equal(t, []float64{runner.Price.Win, 3.2}, moves)
+
+	_, err = s.UpdatePrices(context.Background(), &racing.UpdatePricesRequest{RaceId: 1000, Prices: res.Prices})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.ListPrices(context.Background(), &racing.ListPricesRequest{RaceId: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestValidatePrices(t *testing.T) {
+	runners := []*racing.Runner{
+		{Id: 1, RaceId: 1},
+		{Id: 2, RaceId: 1},
+		{Id: 3, RaceId: 1, Scratched: true},
+	}
+
+	tests := []struct {
+		name   string
+		prices []*racing.Price
+		valid  bool
+	}{
+		{"win and place", []*racing.Price{{RunnerId: 1, Win: 3.5, Place: 1.6}, {RunnerId: 2, Win: 2, Place: 1.2}}, true},
+		{"win only", []*racing.Price{{RunnerId: 1, Win: 1.01}}, true},
+		{"no prices", nil, false},
+		{"unknown runner", []*racing.Price{{RunnerId: 9, Win: 2}}, false},
+		{"scratched runner", []*racing.Price{{RunnerId: 3, Win: 2}}, false},
+		{"runner priced twice", []*racing.Price{{RunnerId: 1, Win: 2}, {RunnerId: 1, Win: 3}}, false},
+		{"no return on the stake", []*racing.Price{{RunnerId: 1, Win: 1}}, false},
+		{"place below the stake", []*racing.Price{{RunnerId: 1, Win: 2, Place: 0.5}}, false},
+		{"place longer than win", []*racing.Price{{RunnerId: 1, Win: 2, Place: 2.5}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePrices(&racing.UpdatePricesRequest{RaceId: 1, Prices: tt.prices}, runners)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
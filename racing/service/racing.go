@@ -1,31 +1,263 @@
 package service
 
 import (
+	"errors"
+	"time"
+
 	"git.neds.sh/matty/entain/racing/db"
 	"git.neds.sh/matty/entain/racing/proto/racing"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type Racing interface {
 	// ListRaces will return a collection of races.
 	ListRaces(ctx context.Context, in *racing.ListRacesRequest) (*racing.ListRacesResponse, error)
+
+	// GetRace will return a single race by its ID.
+	GetRace(ctx context.Context, in *racing.GetRaceRequest) (*racing.Race, error)
+
+	// SearchRaces will return the races matching a text search, most relevant first.
+	SearchRaces(ctx context.Context, in *racing.SearchRacesRequest) (*racing.SearchRacesResponse, error)
+
+	// WatchRaces will stream the races matching the filter, followed by their changes.
+	WatchRaces(in *racing.ListRacesRequestFilter, stream racing.Racing_WatchRacesServer) error
+
+	// ListMeetings will return a collection of meetings.
+	ListMeetings(ctx context.Context, in *racing.ListMeetingsRequest) (*racing.ListMeetingsResponse, error)
+
+	// GetMeeting will return a single meeting by its ID.
+	GetMeeting(ctx context.Context, in *racing.GetMeetingRequest) (*racing.Meeting, error)
+
+	// ListRunners will return the runners of a race.
+	ListRunners(ctx context.Context, in *racing.ListRunnersRequest) (*racing.ListRunnersResponse, error)
+
+	// ResultRace will record the result of a closed race.
+	ResultRace(ctx context.Context, in *racing.ResultRaceRequest) (*racing.RaceResult, error)
+
+	// GetRaceResults will return the result of a race.
+	GetRaceResults(ctx context.Context, in *racing.GetRaceResultsRequest) (*racing.RaceResult, error)
+
+	// CreateRace will create a new race.
+	CreateRace(ctx context.Context, in *racing.CreateRaceRequest) (*racing.Race, error)
+
+	// UpdateRace will update the fields of a race named by the update mask.
+	UpdateRace(ctx context.Context, in *racing.UpdateRaceRequest) (*racing.Race, error)
+
+	// DeleteRace will delete a race.
+	DeleteRace(ctx context.Context, in *racing.DeleteRaceRequest) (*emptypb.Empty, error)
+
+	// ImportRaces will create or update a stream of races by their source ID.
+	ImportRaces(stream racing.Racing_ImportRacesServer) error
+
+	// ListPrices will return the price history of a race's runners.
+	ListPrices(ctx context.Context, in *racing.ListPricesRequest) (*racing.ListPricesResponse, error)
+
+	// UpdatePrices will record new prices for the runners of an open race.
+	UpdatePrices(ctx context.Context, in *racing.UpdatePricesRequest) (*racing.UpdatePricesResponse, error)
 }
 
 // racingService implements the Racing interface.
 type racingService struct {
-	racesRepo db.RacesRepo
+	racesRepo     db.RacesRepo
+	meetingsRepo  db.MeetingsRepo
+	runnersRepo   db.RunnersRepo
+	resultsRepo   db.ResultsRepo
+	pricesRepo    db.PricesRepo
+	watchInterval time.Duration
 }
 
 // NewRacingService instantiates and returns a new racingService.
-func NewRacingService(racesRepo db.RacesRepo) Racing {
-	return &racingService{racesRepo}
+func NewRacingService(racesRepo db.RacesRepo, meetingsRepo db.MeetingsRepo, runnersRepo db.RunnersRepo, resultsRepo db.ResultsRepo, pricesRepo db.PricesRepo) Racing {
+	return &racingService{
+		racesRepo:     racesRepo,
+		meetingsRepo:  meetingsRepo,
+		runnersRepo:   runnersRepo,
+		resultsRepo:   resultsRepo,
+		pricesRepo:    pricesRepo,
+		watchInterval: defaultWatchInterval,
+	}
 }
 
 func (s *racingService) ListRaces(ctx context.Context, in *racing.ListRacesRequest) (*racing.ListRacesResponse, error) {
-	races, err := s.racesRepo.List(in.Filter)
+	filter, filtered, none := restrictFilter(ctx, in.Filter)
+	if none {
+		return &racing.ListRacesResponse{VisibilityFiltered: true}, nil
+	}
+
+	if filtered {
+		in = proto.Clone(in).(*racing.ListRacesRequest)
+		in.Filter = filter
+	}
+
+	races, nextPageToken, err := s.racesRepo.List(in)
 	if err != nil {
+		if isInvalidArgument(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
 		return nil, err
 	}
 
-	return &racing.ListRacesResponse{Races: races}, nil
+	if in.IncludeMeeting {
+		if err := s.embedMeetings(races); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.IncludeRunners {
+		if err := s.embedRunners(races); err != nil {
+			return nil, err
+		}
+	}
+
+	return &racing.ListRacesResponse{Races: races, NextPageToken: nextPageToken, VisibilityFiltered: filtered}, nil
+}
+
+func (s *racingService) GetRace(ctx context.Context, in *racing.GetRaceRequest) (*racing.Race, error) {
+	race, err := s.getVisibleRace(ctx, in.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.IncludeRunners {
+		if err := s.embedRunners([]*racing.Race{race}); err != nil {
+			return nil, err
+		}
+	}
+
+	return race, nil
+}
+
+func (s *racingService) ListMeetings(ctx context.Context, in *racing.ListMeetingsRequest) (*racing.ListMeetingsResponse, error) {
+	meetings, err := s.meetingsRepo.List(in.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &racing.ListMeetingsResponse{Meetings: meetings}, nil
+}
+
+func (s *racingService) GetMeeting(ctx context.Context, in *racing.GetMeetingRequest) (*racing.Meeting, error) {
+	meeting, err := s.meetingsRepo.Get(in.Id)
+	if err != nil {
+		if errors.Is(err, db.ErrMeetingNotFound) {
+			return nil, status.Errorf(codes.NotFound, "meeting %d not found", in.Id)
+		}
+
+		return nil, err
+	}
+
+	return meeting, nil
+}
+
+func (s *racingService) ListRunners(ctx context.Context, in *racing.ListRunnersRequest) (*racing.ListRunnersResponse, error) {
+	if _, err := s.getVisibleRace(ctx, in.RaceId); err != nil {
+		return nil, err
+	}
+
+	runners, err := s.listRunners([]int64{in.RaceId})
+	if err != nil {
+		return nil, err
+	}
+
+	return &racing.ListRunnersResponse{Runners: runners}, nil
+}
+
+// embedMeetings loads the meetings of the given races in a single query, and attaches them to the races.
+func (s *racingService) embedMeetings(races []*racing.Race) error {
+	if len(races) == 0 {
+		return nil
+	}
+
+	var (
+		ids  []int64
+		seen = map[int64]bool{}
+	)
+
+	for _, race := range races {
+		if !seen[race.MeetingId] {
+			seen[race.MeetingId] = true
+			ids = append(ids, race.MeetingId)
+		}
+	}
+
+	meetings, err := s.meetingsRepo.List(&racing.ListMeetingsRequestFilter{Ids: ids})
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int64]*racing.Meeting, len(meetings))
+	for _, meeting := range meetings {
+		byID[meeting.Id] = meeting
+	}
+
+	for _, race := range races {
+		race.Meeting = byID[race.MeetingId]
+	}
+
+	return nil
+}
+
+// embedRunners loads the runners of all the given races in a single query, and attaches them to their races.
+func (s *racingService) embedRunners(races []*racing.Race) error {
+	if len(races) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(races))
+	byID := make(map[int64]*racing.Race, len(races))
+
+	for _, race := range races {
+		ids = append(ids, race.Id)
+		byID[race.Id] = race
+	}
+
+	runners, err := s.listRunners(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, runner := range runners {
+		if race, ok := byID[runner.RaceId]; ok {
+			race.Runners = append(race.Runners, runner)
+		}
+	}
+
+	return nil
+}
+
+// listRunners loads the runners of the given races along with their current prices.
+func (s *racingService) listRunners(raceIDs []int64) ([]*racing.Runner, error) {
+	runners, err := s.runnersRepo.List(raceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := s.pricesRepo.Current(raceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byRunner := make(map[int64]*racing.Price, len(prices))
+	for _, price := range prices {
+		byRunner[price.RunnerId] = price
+	}
+
+	for _, runner := range runners {
+		runner.Price = byRunner[runner.Id]
+	}
+
+	return runners, nil
+}
+
+// isInvalidArgument reports whether the repository rejected the request's parameters.
+func isInvalidArgument(err error) bool {
+	return errors.Is(err, db.ErrInvalidFilter) ||
+		errors.Is(err, db.ErrInvalidOrderBy) ||
+		errors.Is(err, db.ErrInvalidPageSize) ||
+		errors.Is(err, db.ErrInvalidPageToken)
 }
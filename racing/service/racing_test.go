@@ -0,0 +1,178 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRacingService_ListRaces(t *testing.T) {
+	s := createService(t)
+
+	resp, err := s.ListRaces(context.Background(), &racing.ListRacesRequest{IncludeMeeting: true, IncludeRunners: true})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Races)
+
+	for _, race := range resp.Races {
+		if assert.NotNilf(t, race.Meeting, "Race %d has no meeting.", race.Id) {
+			assert.Equal(t, race.MeetingId, race.Meeting.Id)
+		}
+
+		assert.NotEmptyf(t, race.Runners, "Race %d has no runners.", race.Id)
+		for _, runner := range race.Runners {
+			assert.Equal(t, race.Id, runner.RaceId)
+		}
+	}
+
+	_, err = s.ListRaces(context.Background(), &racing.ListRacesRequest{OrderBy: "unknown"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRacingService_GetRace(t *testing.T) {
+	s := createService(t)
+
+	race, err := s.GetRace(context.Background(), &racing.GetRaceRequest{Id: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), race.Id)
+
+	assert.Empty(t, race.Runners)
+
+	race, err = s.GetRace(context.Background(), &racing.GetRaceRequest{Id: 1, IncludeRunners: true})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, race.Runners)
+
+	_, err = s.GetRace(context.Background(), &racing.GetRaceRequest{Id: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRacingService_ListRunners(t *testing.T) {
+	s := createService(t)
+
+	resp, err := s.ListRunners(context.Background(), &racing.ListRunnersRequest{RaceId: 1})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Runners)
+
+	_, err = s.ListRunners(context.Background(), &racing.ListRunnersRequest{RaceId: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRacingService_UpdateRace(t *testing.T) {
+	s := createService(t)
+
+	race, err := s.GetRace(context.Background(), &racing.GetRaceRequest{Id: 1})
+	assert.NoError(t, err)
+
+	updated, err := s.UpdateRace(context.Background(), &racing.UpdateRaceRequest{
+		Race:       &racing.Race{Id: 1, Name: "Renamed", Version: race.Version},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.Name)
+	assert.Equal(t, race.Number, updated.Number)
+	assert.Equal(t, race.Version+1, updated.Version)
+
+	tests := []struct {
+		name string
+		in   *racing.UpdateRaceRequest
+		code codes.Code
+	}{
+		{
+			name: "stale version",
+			in:   &racing.UpdateRaceRequest{Race: &racing.Race{Id: 1, Name: "Stale", Version: race.Version}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}},
+			code: codes.Aborted,
+		},
+		{
+			name: "missing version",
+			in:   &racing.UpdateRaceRequest{Race: &racing.Race{Id: 1, Name: "Unversioned"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "derived field",
+			in:   &racing.UpdateRaceRequest{Race: &racing.Race{Id: 1, Version: updated.Version}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"status"}}},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "invalid value",
+			in:   &racing.UpdateRaceRequest{Race: &racing.Race{Id: 1, Name: " ", Version: updated.Version}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "unknown meeting",
+			in:   &racing.UpdateRaceRequest{Race: &racing.Race{Id: 1, MeetingId: 1000, Version: updated.Version}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"meeting_id"}}},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "unknown race",
+			in:   &racing.UpdateRaceRequest{Race: &racing.Race{Id: 1000, Name: "Missing", Version: 1}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}},
+			code: codes.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.UpdateRace(context.Background(), tt.in)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestRacingService_CreateRace(t *testing.T) {
+	s := createService(t)
+
+	_, err := s.CreateRace(context.Background(), &racing.CreateRaceRequest{Race: &racing.Race{MeetingId: 1, Number: 1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	race, err := s.CreateRace(context.Background(), &racing.CreateRaceRequest{Race: &racing.Race{
+		MeetingId:           1,
+		Name:                "Cup",
+		Number:              1,
+		AdvertisedStartTime: timestamppb.Now(),
+	}})
+	assert.NoError(t, err)
+
+	_, err = s.DeleteRace(context.Background(), &racing.DeleteRaceRequest{Id: race.Id, Version: race.Version})
+	assert.NoError(t, err)
+
+	_, err = s.DeleteRace(context.Background(), &racing.DeleteRaceRequest{Id: race.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func createService(t *testing.T) Racing {
+	racingDB := createDB(t)
+
+	return NewRacingService(
+		db.NewRacesRepo(racingDB),
+		db.NewMeetingsRepo(racingDB),
+		db.NewRunnersRepo(racingDB),
+		db.NewResultsRepo(racingDB),
+		db.NewPricesRepo(racingDB),
+	)
+}
+
+// createDB opens an in-memory database, migrated to the latest schema and seeded.
+func createDB(t *testing.T) *sql.DB {
+	racingDB, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	// Keep the in-memory database alive across the pool's connections.
+	racingDB.SetMaxOpenConns(1)
+
+	migrator, err := db.NewMigrator(racingDB)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	assert.NoError(t, db.Seed(racingDB, db.SeedOptions{Seed: 1, Now: time.Now()}))
+
+	return racingDB
+}
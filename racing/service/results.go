@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *racingService) ResultRace(ctx context.Context, in *racing.ResultRaceRequest) (*racing.RaceResult, error) {
+	race, err := s.racesRepo.Get(in.RaceId)
+	if err != nil {
+		if errors.Is(err, db.ErrRaceNotFound) {
+			return nil, status.Errorf(codes.NotFound, "race %d not found", in.RaceId)
+		}
+
+		return nil, err
+	}
+
+	if race.Status == racing.Race_OPEN {
+		return nil, status.Errorf(codes.FailedPrecondition, "race %d is still open", in.RaceId)
+	}
+
+	runners, err := s.runnersRepo.List([]int64{in.RaceId})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateResult(in, runners); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := s.resultsRepo.Save(&racing.RaceResult{
+		RaceId:   in.RaceId,
+		Status:   in.Status,
+		Placings: in.Placings,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrResultOfficial) {
+			return nil, status.Errorf(codes.FailedPrecondition, "result of race %d is already official", in.RaceId)
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *racingService) GetRaceResults(ctx context.Context, in *racing.GetRaceResultsRequest) (*racing.RaceResult, error) {
+	if _, err := s.getVisibleRace(ctx, in.RaceId); err != nil {
+		return nil, err
+	}
+
+	result, err := s.resultsRepo.Get(in.RaceId)
+	if err != nil {
+		if errors.Is(err, db.ErrResultNotFound) {
+			return nil, status.Errorf(codes.NotFound, "race %d has not been resulted", in.RaceId)
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateResult checks the placings of a result refer to the race's unscratched runners, and that their
+// positions follow on from each other, skipping the positions taken up by dead heats (e.g. 1, 1, 3).
+func validateResult(in *racing.ResultRaceRequest, runners []*racing.Runner) error {
+	if in.Status == racing.RaceResult_STATUS_UNSPECIFIED {
+		return errors.New("status must be specified")
+	}
+
+	if len(in.Placings) == 0 {
+		return errors.New("at least one placing must be given")
+	}
+
+	byID := make(map[int64]*racing.Runner, len(runners))
+	for _, runner := range runners {
+		byID[runner.Id] = runner
+	}
+
+	placed := map[int64]bool{}
+	positions := make([]int64, 0, len(in.Placings))
+
+	for _, placing := range in.Placings {
+		runner, ok := byID[placing.RunnerId]
+		if !ok {
+			return fmt.Errorf("runner %d is not running in race %d", placing.RunnerId, in.RaceId)
+		}
+
+		if runner.Scratched {
+			return fmt.Errorf("runner %d is scratched", placing.RunnerId)
+		}
+
+		if placed[placing.RunnerId] {
+			return fmt.Errorf("runner %d is placed more than once", placing.RunnerId)
+		}
+		placed[placing.RunnerId] = true
+
+		positions = append(positions, placing.Position)
+	}
+
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	// Each position is either shared with the previous runner, or is the previous runner's position
+	// plus the number of runners sharing it.
+	expected := int64(1)
+	for i, position := range positions {
+		if i > 0 && position == positions[i-1] {
+			continue
+		}
+
+		if position != expected {
+			return fmt.Errorf("expected a runner placed %d, got %d", expected, position)
+		}
+
+		expected = position
+		for j := i; j < len(positions) && positions[j] == position; j++ {
+			expected++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateResult(t *testing.T) {
+	runners := []*racing.Runner{
+		{Id: 1, RaceId: 1},
+		{Id: 2, RaceId: 1},
+		{Id: 3, RaceId: 1},
+		{Id: 4, RaceId: 1},
+		{Id: 5, RaceId: 1, Scratched: true},
+	}
+	placings := func(runnerPositions ...int64) []*racing.Placing {
+		var placings []*racing.Placing
+		for i := 0; i < len(runnerPositions); i += 2 {
+			placings = append(placings, &racing.Placing{RunnerId: runnerPositions[i], Position: runnerPositions[i+1]})
+		}
+		return placings
+	}
+
+	tests := []struct {
+		name     string
+		status   racing.RaceResult_Status
+		placings []*racing.Placing
+		valid    bool
+	}{
+		{"straight result", racing.RaceResult_OFFICIAL, placings(1, 1, 2, 2, 3, 3), true},
+		{"dead heat for first", racing.RaceResult_OFFICIAL, placings(1, 1, 2, 1, 3, 3), true},
+		{"dead heat for second", racing.RaceResult_OFFICIAL, placings(1, 1, 2, 2, 3, 2, 4, 4), true},
+		{"winner only", racing.RaceResult_INTERIM, placings(4, 1), true},
+		{"missing status", racing.RaceResult_STATUS_UNSPECIFIED, placings(1, 1), false},
+		{"no placings", racing.RaceResult_OFFICIAL, nil, false},
+		{"unknown runner", racing.RaceResult_OFFICIAL, placings(9, 1), false},
+		{"scratched runner", racing.RaceResult_OFFICIAL, placings(5, 1), false},
+		{"runner placed twice", racing.RaceResult_OFFICIAL, placings(1, 1, 1, 2), false},
+		{"no winner", racing.RaceResult_OFFICIAL, placings(1, 2), false},
+		{"gap in positions", racing.RaceResult_OFFICIAL, placings(1, 1, 2, 3), false},
+		{"position taken by dead heat", racing.RaceResult_OFFICIAL, placings(1, 1, 2, 1, 3, 2), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResult(&racing.ResultRaceRequest{RaceId: 1, Status: tt.status, Placings: tt.placings}, runners)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
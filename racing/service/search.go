@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *racingService) SearchRaces(ctx context.Context, in *racing.SearchRacesRequest) (*racing.SearchRacesResponse, error) {
+	visible, filtered, none := restrictVisibility(ctx, in.Visible)
+	if none {
+		return &racing.SearchRacesResponse{VisibilityFiltered: true}, nil
+	}
+
+	if filtered {
+		in = proto.Clone(in).(*racing.SearchRacesRequest)
+		in.Visible = visible
+	}
+
+	races, err := s.racesRepo.Search(in)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidSearch) || isInvalidArgument(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, err
+	}
+
+	if in.IncludeMeeting {
+		if err := s.embedMeetings(races); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.IncludeRunners {
+		if err := s.embedRunners(races); err != nil {
+			return nil, err
+		}
+	}
+
+	return &racing.SearchRacesResponse{Races: races, VisibilityFiltered: filtered}, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRacingService_SearchRaces(t *testing.T) {
+	s := createService(t)
+
+	race, err := s.GetRace(context.Background(), &racing.GetRaceRequest{Id: 1})
+	require.NoError(t, err)
+
+	resp, err := s.SearchRaces(context.Background(), &racing.SearchRacesRequest{
+		Q:              race.Name,
+		IncludeMeeting: true,
+		IncludeRunners: true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Races)
+
+	var found bool
+	for _, r := range resp.Races {
+		if r.Id == race.Id {
+			found = true
+			assert.NotNil(t, r.Meeting)
+			assert.NotEmpty(t, r.Runners)
+		}
+	}
+	assert.Truef(t, found, "Race %d should match a search for its own name.", race.Id)
+
+	visible := true
+	resp, err = s.SearchRaces(context.Background(), &racing.SearchRacesRequest{Q: race.Name, Visible: &visible})
+	require.NoError(t, err)
+
+	for _, r := range resp.Races {
+		assert.Truef(t, r.Visible, "Race %d isn't visible.", r.Id)
+	}
+
+	_, err = s.SearchRaces(context.Background(), &racing.SearchRacesRequest{Q: " "})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/db"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// visibilityRoles are the roles allowed to see invisible races.
+var visibilityRoles = []string{"trader", "admin"}
+
+// canSeeInvisible reports whether the caller is allowed to see invisible races.
+func canSeeInvisible(ctx context.Context) bool {
+	claims, ok := auth.FromContext(ctx)
+	return ok && claims.HasRole(visibilityRoles...)
+}
+
+// getVisibleRace returns a race, if the caller is allowed to see it. Callers who can't see invisible
+// races can't tell them from races that don't exist, so neither can they see their runners, prices or
+// results.
+func (s *racingService) getVisibleRace(ctx context.Context, id int64) (*racing.Race, error) {
+	race, err := s.racesRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, db.ErrRaceNotFound) {
+			return nil, status.Errorf(codes.NotFound, "race %d not found", id)
+		}
+
+		return nil, err
+	}
+
+	if !race.Visible && !canSeeInvisible(ctx) {
+		return nil, status.Errorf(codes.NotFound, "race %d not found", id)
+	}
+
+	return race, nil
+}
+
+// restrictVisibility narrows a request's visibility criterion to the races the caller is allowed to
+// see. It returns the criterion to apply, whether invisible races are left out against the caller's
+// wishes, and whether nothing the caller asked for can be returned.
+func restrictVisibility(ctx context.Context, visible *bool) (restricted *bool, filtered bool, none bool) {
+	if canSeeInvisible(ctx) || (visible != nil && *visible) {
+		return visible, false, false
+	}
+
+	onlyVisible := true
+
+	return &onlyVisible, true, visible != nil
+}
+
+// restrictFilter returns a copy of the filter narrowed to the races the caller is allowed to see, as
+// restrictVisibility does.
+func restrictFilter(ctx context.Context, filter *racing.ListRacesRequestFilter) (restricted *racing.ListRacesRequestFilter, filtered bool, none bool) {
+	var requested *bool
+	if filter != nil {
+		requested = filter.Visible
+	}
+
+	visible, filtered, none := restrictVisibility(ctx, requested)
+	if !filtered {
+		return filter, false, none
+	}
+
+	restricted = &racing.ListRacesRequestFilter{}
+	if filter != nil {
+		restricted = proto.Clone(filter).(*racing.ListRacesRequestFilter)
+	}
+
+	restricted.Visible = visible
+
+	return restricted, true, none
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"git.neds.sh/matty/entain/racing/auth"
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRacingService_Visibility(t *testing.T) {
+	s := createService(t)
+
+	anonymous := context.Background()
+	retail := auth.NewContext(context.Background(), &auth.Claims{Roles: []string{"retail"}})
+	trader := auth.NewContext(context.Background(), &auth.Claims{Roles: []string{"trader"}})
+
+	visible, invisible := true, false
+
+	// Traders see every race.
+	all, err := s.ListRaces(trader, &racing.ListRacesRequest{})
+	require.NoError(t, err)
+	assert.False(t, all.VisibilityFiltered)
+
+	var hidden *racing.Race
+	for _, race := range all.Races {
+		if !race.Visible {
+			hidden = race
+			break
+		}
+	}
+	require.NotNil(t, hidden, "The seeded races should include invisible ones.")
+
+	for name, ctx := range map[string]context.Context{"anonymous": anonymous, "retail": retail} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := s.ListRaces(ctx, &racing.ListRacesRequest{})
+			require.NoError(t, err)
+			assert.True(t, resp.VisibilityFiltered)
+			assert.NotEmpty(t, resp.Races)
+			for _, race := range resp.Races {
+				assert.Truef(t, race.Visible, "Race %d is invisible.", race.Id)
+			}
+
+			// Asking for invisible races doesn't get around it.
+			resp, err = s.ListRaces(ctx, &racing.ListRacesRequest{Filter: &racing.ListRacesRequestFilter{Visible: &invisible}})
+			require.NoError(t, err)
+			assert.True(t, resp.VisibilityFiltered)
+			assert.Empty(t, resp.Races)
+
+			// Nothing is filtered out of visible races.
+			resp, err = s.ListRaces(ctx, &racing.ListRacesRequest{Filter: &racing.ListRacesRequestFilter{Visible: &visible}})
+			require.NoError(t, err)
+			assert.False(t, resp.VisibilityFiltered)
+
+			// Nor can anything about the race be fetched.
+			_, err = s.GetRace(ctx, &racing.GetRaceRequest{Id: hidden.Id})
+			assert.Equal(t, codes.NotFound, status.Code(err))
+
+			_, err = s.ListRunners(ctx, &racing.ListRunnersRequest{RaceId: hidden.Id})
+			assert.Equal(t, codes.NotFound, status.Code(err))
+
+			_, err = s.ListPrices(ctx, &racing.ListPricesRequest{RaceId: hidden.Id})
+			assert.Equal(t, codes.NotFound, status.Code(err))
+
+			_, err = s.GetRaceResults(ctx, &racing.GetRaceResultsRequest{RaceId: hidden.Id})
+			assert.Equal(t, codes.NotFound, status.Code(err))
+			assert.Equal(t, fmt.Sprintf("race %d not found", hidden.Id), status.Convert(err).Message())
+
+			search, err := s.SearchRaces(ctx, &racing.SearchRacesRequest{Q: hidden.Name})
+			require.NoError(t, err)
+			assert.True(t, search.VisibilityFiltered)
+			for _, race := range search.Races {
+				assert.Truef(t, race.Visible, "Race %d is invisible.", race.Id)
+			}
+
+			err = s.WatchRaces(&racing.ListRacesRequestFilter{Visible: &invisible}, &fakeWatchStream{ctx: ctx})
+			assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+	}
+
+	resp, err := s.ListRaces(trader, &racing.ListRacesRequest{Filter: &racing.ListRacesRequestFilter{Visible: &invisible}})
+	require.NoError(t, err)
+	assert.False(t, resp.VisibilityFiltered)
+	assert.NotEmpty(t, resp.Races)
+
+	race, err := s.GetRace(auth.NewContext(context.Background(), &auth.Claims{Roles: []string{"admin"}}), &racing.GetRaceRequest{Id: hidden.Id})
+	require.NoError(t, err)
+	assert.Equal(t, hidden.Id, race.Id)
+
+	_, err = s.ListRunners(trader, &racing.ListRunnersRequest{RaceId: hidden.Id})
+	assert.NoError(t, err)
+
+	_, err = s.ListPrices(trader, &racing.ListPricesRequest{RaceId: hidden.Id})
+	assert.NoError(t, err)
+}
+
+// fakeWatchStream is a WatchRaces stream dropping the events sent to it.
+type fakeWatchStream struct {
+	racing.Racing_WatchRacesServer
+	ctx context.Context
+}
+
+func (s *fakeWatchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeWatchStream) Send(*racing.RaceEvent) error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"time"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultWatchInterval is how often WatchRaces polls the repository for changes.
+const defaultWatchInterval = 2 * time.Second
+
+func (s *racingService) WatchRaces(in *racing.ListRacesRequestFilter, stream racing.Racing_WatchRacesServer) error {
+	if in.GetNextToJump() != 0 {
+		return status.Error(codes.InvalidArgument, "next_to_jump can't be watched")
+	}
+
+	in, _, none := restrictFilter(stream.Context(), in)
+	if none {
+		return status.Error(codes.PermissionDenied, "invisible races can only be watched by traders and admins")
+	}
+
+	// Races are polled regardless of status, so that races which flip out of a status filter are
+	// still seen changing. The status filter is applied to the events instead.
+	pollFilter := proto.Clone(in).(*racing.ListRacesRequestFilter)
+	if pollFilter == nil {
+		pollFilter = &racing.ListRacesRequestFilter{}
+	}
+	pollFilter.Status = nil
+
+	matches := func(race *racing.Race) bool {
+		return in.Status == nil || race.Status == *in.Status
+	}
+
+	races, err := s.pollRaces(pollFilter)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[int64]*racing.Race, len(races))
+	for _, race := range races {
+		known[race.Id] = race
+
+		if matches(race) {
+			if err := stream.Send(&racing.RaceEvent{Type: racing.RaceEvent_SNAPSHOT, Race: race, Time: timestamppb.Now()}); err != nil {
+				return err
+			}
+		}
+	}
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		races, err := s.pollRaces(pollFilter)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range diffRaces(known, races, matches) {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pollRaces lists every race matching the filter.
+func (s *racingService) pollRaces(filter *racing.ListRacesRequestFilter) ([]*racing.Race, error) {
+	races, _, err := s.racesRepo.List(&racing.ListRacesRequest{Filter: filter})
+	if err != nil {
+		if isInvalidArgument(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, err
+	}
+
+	return races, nil
+}
+
+// diffRaces compares freshly polled races against the known ones, returning an event for each change
+// that concerns a matching race, and updating known in place.
+func diffRaces(known map[int64]*racing.Race, races []*racing.Race, matches func(*racing.Race) bool) []*racing.RaceEvent {
+	var (
+		events []*racing.RaceEvent
+		now    = timestamppb.Now()
+	)
+
+	polled := make(map[int64]bool, len(races))
+
+	for _, race := range races {
+		polled[race.Id] = true
+
+		prev, seen := known[race.Id]
+		known[race.Id] = race
+
+		var eventType racing.RaceEvent_Type
+
+		switch {
+		case !seen:
+			eventType = racing.RaceEvent_CREATED
+		case prev.Status != racing.Race_CLOSED && race.Status == racing.Race_CLOSED:
+			eventType = racing.RaceEvent_CLOSED
+		case prev.Status != racing.Race_RESULTED && race.Status == racing.Race_RESULTED:
+			eventType = racing.RaceEvent_RESULTED
+		case !proto.Equal(prev, race):
+			eventType = racing.RaceEvent_UPDATED
+		default:
+			continue
+		}
+
+		// Races moving out of a status filter are still reported, so watchers see them leave.
+		if matches(race) || (seen && matches(prev)) {
+			events = append(events, &racing.RaceEvent{Type: eventType, Race: race, Time: now})
+		}
+	}
+
+	for id, race := range known {
+		if polled[id] {
+			continue
+		}
+
+		delete(known, id)
+
+		if matches(race) {
+			events = append(events, &racing.RaceEvent{Type: racing.RaceEvent_DELETED, Race: race, Time: now})
+		}
+	}
+
+	return events
+}
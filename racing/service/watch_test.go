@@ -0,0 +1,108 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"git.neds.sh/matty/entain/racing/proto/racing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDiffRaces(t *testing.T) {
+	start := timestamppb.New(time.Now().Add(time.Hour))
+	open := func(id int64, name string) *racing.Race {
+		return &racing.Race{Id: id, Name: name, AdvertisedStartTime: start, Status: racing.Race_OPEN}
+	}
+	closed := func(id int64, name string) *racing.Race {
+		return &racing.Race{Id: id, Name: name, AdvertisedStartTime: start, Status: racing.Race_CLOSED}
+	}
+	all := func(*racing.Race) bool { return true }
+	onlyOpen := func(race *racing.Race) bool { return race.Status == racing.Race_OPEN }
+
+	tests := []struct {
+		name    string
+		known   []*racing.Race
+		polled  []*racing.Race
+		matches func(*racing.Race) bool
+		want    map[int64]racing.RaceEvent_Type
+	}{
+		{
+			name:    "unchanged races emit nothing",
+			known:   []*racing.Race{open(1, "A")},
+			polled:  []*racing.Race{open(1, "A")},
+			matches: all,
+			want:    map[int64]racing.RaceEvent_Type{},
+		},
+		{
+			name:    "new race is created",
+			known:   []*racing.Race{open(1, "A")},
+			polled:  []*racing.Race{open(1, "A"), open(2, "B")},
+			matches: all,
+			want:    map[int64]racing.RaceEvent_Type{2: racing.RaceEvent_CREATED},
+		},
+		{
+			name:    "changed race is updated",
+			known:   []*racing.Race{open(1, "A")},
+			polled:  []*racing.Race{open(1, "A renamed")},
+			matches: all,
+			want:    map[int64]racing.RaceEvent_Type{1: racing.RaceEvent_UPDATED},
+		},
+		{
+			name:    "status flip is closed",
+			known:   []*racing.Race{open(1, "A")},
+			polled:  []*racing.Race{closed(1, "A")},
+			matches: all,
+			want:    map[int64]racing.RaceEvent_Type{1: racing.RaceEvent_CLOSED},
+		},
+		{
+			name:    "official result is resulted",
+			known:   []*racing.Race{closed(1, "A")},
+			polled:  []*racing.Race{{Id: 1, Name: "A", AdvertisedStartTime: start, Status: racing.Race_RESULTED}},
+			matches: all,
+			want:    map[int64]racing.RaceEvent_Type{1: racing.RaceEvent_RESULTED},
+		},
+		{
+			name:    "missing race is deleted",
+			known:   []*racing.Race{open(1, "A"), open(2, "B")},
+			polled:  []*racing.Race{open(1, "A")},
+			matches: all,
+			want:    map[int64]racing.RaceEvent_Type{2: racing.RaceEvent_DELETED},
+		},
+		{
+			name:    "race leaving the status filter is still closed",
+			known:   []*racing.Race{open(1, "A")},
+			polled:  []*racing.Race{closed(1, "A")},
+			matches: onlyOpen,
+			want:    map[int64]racing.RaceEvent_Type{1: racing.RaceEvent_CLOSED},
+		},
+		{
+			name:    "changes outside the status filter are ignored",
+			known:   []*racing.Race{closed(1, "A")},
+			polled:  []*racing.Race{closed(1, "A renamed"), closed(2, "B")},
+			matches: onlyOpen,
+			want:    map[int64]racing.RaceEvent_Type{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known := map[int64]*racing.Race{}
+			for _, race := range tt.known {
+				known[race.Id] = race
+			}
+
+			got := map[int64]racing.RaceEvent_Type{}
+			for _, event := range diffRaces(known, tt.polled, tt.matches) {
+				got[event.Race.Id] = event.Type
+			}
+
+			assert.Equal(t, tt.want, got)
+
+			assert.Len(t, known, len(tt.polled))
+			for _, race := range tt.polled {
+				assert.Same(t, race, known[race.Id])
+			}
+		})
+	}
+}
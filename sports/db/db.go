@@ -0,0 +1,33 @@
+package db
+
+import (
+	"time"
+
+	"syreclabs.com/go/faker"
+)
+
+// seedSports is the set of sports seeded events are drawn from.
+var seedSports = []string{"Soccer", "Basketball", "Tennis", "Cricket", "Rugby League", "AFL"}
+
+func (r *eventsRepo) seed() error {
+	statement, err := r.db.Prepare(`CREATE TABLE IF NOT EXISTS events (id INTEGER PRIMARY KEY, sport TEXT, competition TEXT, name TEXT, visible INTEGER, advertised_start_time DATETIME)`)
+	if err == nil {
+		_, err = statement.Exec()
+	}
+
+	for i := 1; i <= 100; i++ {
+		statement, err = r.db.Prepare(`INSERT OR IGNORE INTO events(id, sport, competition, name, visible, advertised_start_time) VALUES (?,?,?,?,?,?)`)
+		if err == nil {
+			_, err = statement.Exec(
+				i,
+				faker.RandomChoice(seedSports),
+				faker.Company().Name()+" Cup",
+				faker.Team().Name()+" vs "+faker.Team().Name(),
+				faker.Number().Between(0, 1),
+				faker.Time().Between(time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 2)).UTC().Format(time.RFC3339),
+			)
+		}
+	}
+
+	return err
+}
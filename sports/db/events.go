@@ -0,0 +1,142 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	_ "github.com/mattn/go-sqlite3"
+
+	"git.neds.sh/matty/entain/sports/proto/sports"
+)
+
+// EventsRepo provides repository access to sports events.
+type EventsRepo interface {
+	// Init will initialise our events repository.
+	Init() error
+
+	// List will return a list of events matching the request's filter, in the requested order.
+	List(in *sports.ListEventsRequest) ([]*sports.Event, error)
+}
+
+type eventsRepo struct {
+	db   *sql.DB
+	init sync.Once
+}
+
+// NewEventsRepo creates a new events repository.
+func NewEventsRepo(db *sql.DB) EventsRepo {
+	return &eventsRepo{db: db}
+}
+
+// Init prepares the events repository dummy data.
+func (r *eventsRepo) Init() error {
+	var err error
+
+	r.init.Do(func() {
+		// For test/example purposes, we seed the DB with some dummy events.
+		err = r.seed()
+	})
+
+	return err
+}
+
+func (r *eventsRepo) List(in *sports.ListEventsRequest) ([]*sports.Event, error) {
+	var (
+		err   error
+		query string
+		args  []interface{}
+	)
+
+	// Validate the ordering before building any SQL, so unknown fields never reach the query.
+	order, err := parseOrderBy(in.GetOrderBy())
+	if err != nil {
+		return nil, err
+	}
+
+	query = getEventQueries()[eventsList]
+
+	query, args = r.applyFilter(query, in.GetFilter())
+
+	query += orderByClause(order)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.scanEvents(rows)
+}
+
+func (r *eventsRepo) applyFilter(query string, filter *sports.ListEventsRequestFilter) (string, []interface{}) {
+	var (
+		clauses []string
+		args    []interface{}
+	)
+
+	if filter == nil {
+		return query, args
+	}
+
+	if len(filter.Sports) > 0 {
+		clauses = append(clauses, "sport IN ("+strings.Repeat("?,", len(filter.Sports)-1)+"?)")
+
+		for _, sport := range filter.Sports {
+			args = append(args, sport)
+		}
+	}
+
+	// When visible parameter is included in the request, the visible filter is applied
+	if filter.Visible != nil {
+		clauses = append(clauses, "visible = ?")
+		args = append(args, filter.Visible)
+	}
+
+	if len(clauses) != 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	return query, args
+}
+
+func (r *eventsRepo) scanEvents(
+	rows *sql.Rows,
+) ([]*sports.Event, error) {
+	var events []*sports.Event
+
+	for rows.Next() {
+		var event sports.Event
+		var advertisedStart time.Time
+
+		if err := rows.Scan(&event.Id, &event.Sport, &event.Competition, &event.Name, &event.Visible, &advertisedStart); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		ts, err := ptypes.TimestampProto(advertisedStart)
+		if err != nil {
+			return nil, err
+		}
+
+		event.AdvertisedStartTime = ts
+		event.Status = eventStatus(advertisedStart, time.Now())
+
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+// eventStatus derives the status of an event from its advertised start time.
+func eventStatus(advertisedStart time.Time, now time.Time) sports.Event_Status {
+	if advertisedStart.After(now) {
+		return sports.Event_OPEN
+	}
+
+	return sports.Event_CLOSED
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"git.neds.sh/matty/entain/sports/proto/sports"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+EventsRepo test class with different filters in ListEventsRequestFilter
+*/
+func TestEventsRepoVisibleFilter_List(t *testing.T) {
+	eventsRepo := createRepo(t)
+
+	visible := true
+	filter := &sports.ListEventsRequestFilter{
+		Visible: &visible,
+	}
+	events, err := eventsRepo.List(&sports.ListEventsRequest{Filter: filter})
+	assert.NoError(t, err)
+
+	for _, event := range events {
+		assert.Truef(t, event.Visible, "Event %d is not visible.", event.Id)
+	}
+}
+
+func TestEventsRepoSportsFilter_List(t *testing.T) {
+	eventsRepo := createRepo(t)
+
+	filter := &sports.ListEventsRequestFilter{
+		Sports: []string{"Tennis", "Cricket"},
+	}
+	events, err := eventsRepo.List(&sports.ListEventsRequest{Filter: filter})
+	assert.NoError(t, err)
+
+	for _, event := range events {
+		assert.Containsf(t, filter.Sports, event.Sport, "Event %d has an unexpected sport.", event.Id)
+	}
+}
+
+func TestEventsRepoAll_List(t *testing.T) {
+	eventsRepo := createRepo(t)
+
+	events, err := eventsRepo.List(&sports.ListEventsRequest{})
+	assert.NoError(t, err)
+	assert.Equalf(t, 100, len(events), "There should be a total of 100 events in DB.")
+
+	// Events default to being ordered by their advertised start time.
+	for i := 1; i < len(events); i++ {
+		prev, curr := events[i-1].AdvertisedStartTime.AsTime(), events[i].AdvertisedStartTime.AsTime()
+		assert.Falsef(t, curr.Before(prev), "Event %d starts before event %d.", events[i].Id, events[i-1].Id)
+	}
+}
+
+func TestEventsRepoOrderBy_List(t *testing.T) {
+	eventsRepo := createRepo(t)
+
+	events, err := eventsRepo.List(&sports.ListEventsRequest{OrderBy: "sport desc, name"})
+	assert.NoError(t, err)
+
+	for i := 1; i < len(events); i++ {
+		prev, curr := events[i-1], events[i]
+		assert.GreaterOrEqual(t, prev.Sport, curr.Sport)
+		if prev.Sport == curr.Sport {
+			assert.LessOrEqual(t, prev.Name, curr.Name)
+		}
+	}
+
+	_, err = eventsRepo.List(&sports.ListEventsRequest{OrderBy: "unknown"})
+	assert.ErrorIs(t, err, ErrInvalidOrderBy)
+}
+
+func createRepo(t *testing.T) EventsRepo {
+	sportsDB, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	repo := NewEventsRepo(sportsDB)
+	err = repo.Init()
+	assert.NoError(t, err)
+
+	return repo
+}
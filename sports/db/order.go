@@ -0,0 +1,94 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidOrderBy is returned when an order_by clause references an unknown field or direction.
+var ErrInvalidOrderBy = errors.New("invalid order_by")
+
+// defaultEventOrderBy is applied when the caller doesn't ask for a specific ordering.
+const defaultEventOrderBy = "advertised_start_time"
+
+// eventOrderColumns whitelists the event fields that can be sorted on, mapped to their column.
+var eventOrderColumns = map[string]string{
+	"id":                    "id",
+	"sport":                 "sport",
+	"competition":           "competition",
+	"name":                  "name",
+	"visible":               "visible",
+	"advertised_start_time": "advertised_start_time",
+}
+
+// orderField is a single, validated, term of an ORDER BY clause.
+type orderField struct {
+	column string
+	desc   bool
+}
+
+// parseOrderBy validates an order_by value against the whitelisted event fields.
+// The event ID is always appended as a final tie-breaker so the ordering is total.
+func parseOrderBy(orderBy string) ([]orderField, error) {
+	if strings.TrimSpace(orderBy) == "" {
+		orderBy = defaultEventOrderBy
+	}
+
+	var (
+		fields []orderField
+		seen   = map[string]bool{}
+	)
+
+	for _, term := range strings.Split(orderBy, ",") {
+		parts := strings.Fields(term)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("%w: malformed term %q", ErrInvalidOrderBy, strings.TrimSpace(term))
+		}
+
+		column, ok := eventOrderColumns[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidOrderBy, parts[0])
+		}
+
+		if seen[column] {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidOrderBy, parts[0])
+		}
+		seen[column] = true
+
+		field := orderField{column: column}
+		if len(parts) == 2 {
+			switch strings.ToLower(parts[1]) {
+			case "asc":
+			case "desc":
+				field.desc = true
+			default:
+				return nil, fmt.Errorf("%w: unknown direction %q", ErrInvalidOrderBy, parts[1])
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	if !seen["id"] {
+		fields = append(fields, orderField{column: "id"})
+	}
+
+	return fields, nil
+}
+
+// orderByClause renders the validated fields as an SQL ORDER BY clause.
+func orderByClause(fields []orderField) string {
+	terms := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		direction := "ASC"
+		if field.desc {
+			direction = "DESC"
+		}
+
+		terms = append(terms, field.column+" "+direction)
+	}
+
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
@@ -0,0 +1,20 @@
+package db
+
+const (
+	eventsList = "list"
+)
+
+func getEventQueries() map[string]string {
+	return map[string]string{
+		eventsList: `
+			SELECT 
+				id, 
+				sport, 
+				competition, 
+				name, 
+				visible, 
+				advertised_start_time 
+			FROM events
+		`,
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+
+	"git.neds.sh/matty/entain/sports/db"
+	"git.neds.sh/matty/entain/sports/proto/sports"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Sports interface {
+	// ListEvents will return a collection of events.
+	ListEvents(ctx context.Context, in *sports.ListEventsRequest) (*sports.ListEventsResponse, error)
+}
+
+// sportsService implements the Sports interface.
+type sportsService struct {
+	eventsRepo db.EventsRepo
+}
+
+// NewSportsService instantiates and returns a new sportsService.
+func NewSportsService(eventsRepo db.EventsRepo) Sports {
+	return &sportsService{eventsRepo}
+}
+
+func (s *sportsService) ListEvents(ctx context.Context, in *sports.ListEventsRequest) (*sports.ListEventsResponse, error) {
+	events, err := s.eventsRepo.List(in)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidOrderBy) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, err
+	}
+
+	return &sports.ListEventsResponse{Events: events}, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"git.neds.sh/matty/entain/sports/db"
+	"git.neds.sh/matty/entain/sports/proto/sports"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSportsService_ListEvents(t *testing.T) {
+	s := createService(t)
+
+	resp, err := s.ListEvents(context.Background(), &sports.ListEventsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Events, 100)
+
+	// Events default to being ordered by their advertised start time.
+	for i := 1; i < len(resp.Events); i++ {
+		prev, curr := resp.Events[i-1], resp.Events[i]
+		assert.Falsef(t, curr.AdvertisedStartTime.AsTime().Before(prev.AdvertisedStartTime.AsTime()), "Event %d starts before event %d.", curr.Id, prev.Id)
+	}
+}
+
+func TestSportsService_ListEventsOrderBy(t *testing.T) {
+	s := createService(t)
+
+	resp, err := s.ListEvents(context.Background(), &sports.ListEventsRequest{OrderBy: "sport, advertised_start_time DESC"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Events)
+
+	for i := 1; i < len(resp.Events); i++ {
+		prev, curr := resp.Events[i-1], resp.Events[i]
+		assert.LessOrEqual(t, prev.Sport, curr.Sport)
+		if prev.Sport == curr.Sport {
+			assert.Falsef(t, curr.AdvertisedStartTime.AsTime().After(prev.AdvertisedStartTime.AsTime()), "Event %d starts after event %d.", curr.Id, prev.Id)
+		}
+	}
+
+	// Ties are broken by ID.
+	resp, err = s.ListEvents(context.Background(), &sports.ListEventsRequest{OrderBy: "visible"})
+	assert.NoError(t, err)
+
+	for i := 1; i < len(resp.Events); i++ {
+		prev, curr := resp.Events[i-1], resp.Events[i]
+		if prev.Visible == curr.Visible {
+			assert.Less(t, prev.Id, curr.Id)
+		}
+	}
+}
+
+func TestSportsService_ListEventsInvalidOrderBy(t *testing.T) {
+	s := createService(t)
+
+	for _, orderBy := range []string{"unknown", "name sideways", "name, name", "name asc desc", "name,"} {
+		t.Run(orderBy, func(t *testing.T) {
+			_, err := s.ListEvents(context.Background(), &sports.ListEventsRequest{OrderBy: orderBy})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestSportsService_ListEventsRepoError(t *testing.T) {
+	s := NewSportsService(&failingEventsRepo{err: errors.New("database is locked")})
+
+	// Only invalid requests are the caller's fault.
+	_, err := s.ListEvents(context.Background(), &sports.ListEventsRequest{})
+	assert.Equal(t, codes.Unknown, status.Code(err))
+}
+
+// failingEventsRepo is an EventsRepo failing every list.
+type failingEventsRepo struct {
+	db.EventsRepo
+	err error
+}
+
+func (r *failingEventsRepo) List(*sports.ListEventsRequest) ([]*sports.Event, error) {
+	return nil, r.err
+}
+
+// createService creates a service over an in-memory database of seeded events.
+func createService(t *testing.T) Sports {
+	sportsDB, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	// Keep the in-memory database alive across the pool's connections.
+	sportsDB.SetMaxOpenConns(1)
+
+	eventsRepo := db.NewEventsRepo(sportsDB)
+	assert.NoError(t, eventsRepo.Init())
+
+	return NewSportsService(eventsRepo)
+}